@@ -0,0 +1,314 @@
+// Package app builds the application from its configuration: the database,
+// repositories, services, controllers and the router serving them. Every
+// dependency is passed explicitly, so several instances can live side by
+// side and tests can serve requests through Handler.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-crud/config"
+	"golang-crud/controllers"
+	"golang-crud/health"
+	"golang-crud/initializers"
+	"golang-crud/jobs"
+	"golang-crud/middlewares"
+	"golang-crud/migrations"
+	"golang-crud/oauth"
+	"golang-crud/repository"
+	"golang-crud/security"
+	"golang-crud/service"
+	"golang-crud/validation"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type App struct {
+	Config *config.Config
+	DB     *gorm.DB
+
+	router       *gin.Engine
+	providers    *oauth.Registry
+	trashService *service.TrashService
+	hooks        []hook
+}
+
+// hook is a shutdown step, named in the logs.
+type hook struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Option provides a dependency New would otherwise build from the
+// configuration.
+type Option func(*options)
+
+type options struct {
+	db             *gorm.DB
+	skipMigrations bool
+	repos          Repositories
+	keys           *security.KeyManager
+	providers      *oauth.Registry
+}
+
+// Repositories is the data access the services are built on. Fields left
+// nil by WithRepositories are built on the database.
+type Repositories struct {
+	Users         repository.UserRepository
+	Companies     *repository.CompanyRepository
+	Posts         *repository.PostRepository
+	RefreshTokens *repository.RefreshTokenRepository
+	Identities    *repository.ExternalIdentityRepository
+	Roles         *repository.RoleRepository
+	Trash         *repository.TrashRepository
+}
+
+func (r *Repositories) fill(db *gorm.DB) {
+	if r.Users == nil {
+		r.Users = repository.NewUserRepository(db)
+	}
+	if r.Companies == nil {
+		r.Companies = repository.NewCompanyRepository(db)
+	}
+	if r.Posts == nil {
+		r.Posts = repository.NewPostRepository(db)
+	}
+	if r.RefreshTokens == nil {
+		r.RefreshTokens = repository.NewRefreshTokenRepository(db)
+	}
+	if r.Identities == nil {
+		r.Identities = repository.NewExternalIdentityRepository(db)
+	}
+	if r.Roles == nil {
+		r.Roles = repository.NewRoleRepository(db)
+	}
+	if r.Trash == nil {
+		r.Trash = repository.NewTrashRepository(db)
+	}
+}
+
+// WithDB uses db instead of connecting to the configured database, which
+// can be any database gorm supports, such as SQLite in tests. It is left
+// open on shutdown.
+func WithDB(db *gorm.DB) Option {
+	return func(o *options) { o.db = db }
+}
+
+// WithoutMigrations leaves the schema alone whatever the configuration, for
+// databases whose schema was created by the caller. Built-in roles are
+// still seeded.
+func WithoutMigrations() Option {
+	return func(o *options) { o.skipMigrations = true }
+}
+
+// WithRepositories uses the given repositories instead of building them on
+// the database.
+func WithRepositories(repos Repositories) Option {
+	return func(o *options) { o.repos = repos }
+}
+
+// WithProviders signs users in with the providers of registry instead of
+// the configured ones.
+func WithProviders(registry *oauth.Registry) Option {
+	return func(o *options) { o.providers = registry }
+}
+
+// WithKeyManager signs access tokens with keys instead of the configured ones.
+func WithKeyManager(keys *security.KeyManager) Option {
+	return func(o *options) { o.keys = keys }
+}
+
+// New connects to the database, applies the migrations if configured to and
+// wires every component together. On error whatever was started is stopped.
+func New(cfg *config.Config, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	app := &App{Config: cfg, DB: o.db}
+	if app.DB == nil {
+		db, err := initializers.ConnectToDB(cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		app.DB = db
+		app.OnShutdown("database", closeDB(db))
+	}
+
+	if err := app.init(o); err != nil {
+		app.Shutdown(context.Background())
+		return nil, err
+	}
+	return app, nil
+}
+
+func (a *App) init(o options) error {
+	database := a.Config.Database
+	if o.skipMigrations {
+		database.AutoMigrate = false
+	}
+	if err := initializers.MigrateDB(a.DB, database); err != nil {
+		return err
+	}
+
+	keys := o.keys
+	if keys == nil {
+		var err error
+		if keys, err = initializers.LoadSigningKeys(a.Config.JWT); err != nil {
+			return err
+		}
+	}
+	a.providers = o.providers
+	if a.providers == nil {
+		var err error
+		if a.providers, err = initializers.ConnectToProviders(a.Config.Auth); err != nil {
+			return err
+		}
+	}
+
+	accessTokens := security.NewAccessTokens(keys, initializers.LoadTokenOptions(a.Config.JWT))
+
+	// Set up repositories and services
+	repos := o.repos
+	repos.fill(a.DB)
+	validator := validation.New(repos.Users)
+
+	userService := service.NewUserServiceImpl(repos.Users)
+	tokenService := service.NewTokenService(repos.RefreshTokens, repos.Users, accessTokens)
+	roleService := service.NewRoleService(repos.Roles)
+	identityService := service.NewIdentityService(repos.Identities, repos.Users, initializers.LoadProvisioningOptions(a.Config.Provisioning))
+	a.trashService = service.NewTrashService(repos.Trash)
+
+	readiness, err := a.readiness()
+	if err != nil {
+		return err
+	}
+
+	a.router = newRouter(middlewares.NewAuth(accessTokens, repos.Users, repos.Roles, repos.RefreshTokens), handlers{
+		auth:    controllers.NewGoAuthController(identityService, tokenService, a.providers),
+		company: controllers.NewCompanyController(service.NewCompanyServiceImpl(repos.Companies), validator),
+		post:    controllers.NewPostController(service.NewPostService(repos.Posts), validator),
+		user:    controllers.NewUserController(userService, tokenService, roleService, validator),
+		token:   controllers.NewTokenController(tokenService),
+		jwks:    controllers.NewJWKSController(keys),
+		role:    controllers.NewRoleController(roleService),
+		trash:   controllers.NewTrashController(a.trashService),
+		health:  controllers.NewHealthController(readiness),
+	})
+	return nil
+}
+
+// readiness checks the database and its schema. Identity providers aren't
+// checked: an outage on their side only breaks social login, and taking
+// every replica out of the load balancer wouldn't fix it.
+func (a *App) readiness() (*health.Health, error) {
+	migrationList, err := migrations.Load(migrations.Embedded())
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+
+	readiness := health.New(a.Config.Health.Timeout)
+	readiness.Register("database", health.Database(a.DB))
+	readiness.Register("migrations", health.Migrations(migrations.New(a.DB, migrationList)))
+	return readiness, nil
+}
+
+// Handler serves the API, without starting the background jobs.
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// Run starts the background jobs and serves the API on the configured
+// address until ctx is cancelled or the server fails. It then stops taking
+// connections, waits for in-flight requests and runs the shutdown hooks,
+// all within the configured shutdown timeout.
+func (a *App) Run(ctx context.Context) error {
+	// Listen first so a busy address fails the start before anything runs
+	settings := a.Config.HTTP
+	listener, err := net.Listen("tcp", settings.Addr)
+	if err != nil {
+		return errors.Join(fmt.Errorf("listen: %w", err), a.Shutdown(context.Background()))
+	}
+
+	// Permanently delete records once they have been in the trash past the retention period
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	purgerDone := jobs.StartTrashPurger(jobsCtx, a.trashService, a.Config.Trash.Retention, a.Config.Trash.PurgeInterval)
+	a.OnShutdown("trash purger", func(ctx context.Context) error {
+		stopJobs()
+		select {
+		case <-purgerDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	server := &http.Server{
+		Addr:              settings.Addr,
+		Handler:           a.router,
+		ReadHeaderTimeout: settings.ReadHeaderTimeout,
+		ReadTimeout:       settings.ReadTimeout,
+		WriteTimeout:      settings.WriteTimeout,
+		IdleTimeout:       settings.IdleTimeout,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	log.Println("Listening on ", listener.Addr())
+
+	select {
+	case err = <-served:
+		err = fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+		log.Println("Shutting down, draining in-flight requests")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), settings.ShutdownTimeout)
+	defer cancel()
+	if err == nil {
+		if drainErr := server.Shutdown(shutdownCtx); drainErr != nil {
+			err = fmt.Errorf("drain requests: %w", drainErr)
+		}
+	}
+	return errors.Join(err, a.Shutdown(shutdownCtx))
+}
+
+// OnShutdown registers a step run by Shutdown. Steps run in the reverse
+// order they were registered, so a component stops before the ones it was
+// started after, such as background jobs before the database.
+func (a *App) OnShutdown(name string, run func(ctx context.Context) error) {
+	a.hooks = append(a.hooks, hook{name: name, run: run})
+}
+
+// Shutdown runs every shutdown hook once, even if some fail, and returns
+// their errors.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	for i := len(a.hooks) - 1; i >= 0; i-- {
+		hook := a.hooks[i]
+		if err := hook.run(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.name, err))
+			continue
+		}
+		log.Println("Stopped ", hook.name)
+	}
+	a.hooks = nil
+	return errors.Join(errs...)
+}
+
+func closeDB(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"golang-crud/controllers"
+	"golang-crud/enum"
+	"golang-crud/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handlers are the controllers the routes dispatch to.
+type handlers struct {
+	auth    *controllers.GoAuthController
+	company *controllers.CompanyController
+	post    *controllers.PostController
+	user    *controllers.UserController
+	token   *controllers.TokenController
+	jwks    *controllers.JWKSController
+	role    *controllers.RoleController
+	trash   *controllers.TrashController
+	health  *controllers.HealthController
+}
+
+func newRouter(auth *middlewares.Auth, h handlers) *gin.Engine {
+	// Create a Gin router
+	r := gin.Default()
+
+	// Tag every request with an ID and report handler errors as problem+json
+	r.Use(middlewares.RequestID(), middlewares.ErrorHandler())
+
+	// Kubernetes probes
+	r.GET("/healthz", h.health.Liveness)
+	r.GET("/readyz", h.health.Readiness)
+
+	// Define the home route
+	r.GET("/", h.auth.HandleHome)
+
+	// Social login routes, one pair per enabled identity provider
+	r.GET("/auth/:provider/login", h.auth.SignInWithProvider)
+	r.GET("/auth/:provider/callback", h.auth.CallbackHandler)
+	r.POST("/auth/:provider/link", auth.RequirePermission(), h.auth.LinkProvider)
+
+	//Company API's
+	r.POST("/company", auth.RequirePermission(enum.CompaniesWrite), h.company.CreateCompany)
+	r.GET("/getAllCompanies", auth.RequirePermission(enum.CompaniesRead), h.company.GetAllCompanies)
+	r.DELETE("/deleteCompany/:id", auth.RequirePermission(enum.CompaniesDelete), h.company.DeleteCompany)
+	companyRoutes := r.Group("/company")
+	{
+		companyRoutes.GET("/:id", auth.RequirePermission(enum.CompaniesRead), h.company.GetCompanyById)
+		companyRoutes.PUT("/:id", auth.RequirePermission(enum.CompaniesWrite), h.company.UpdateCompany)
+		companyRoutes.PATCH("/:id", auth.RequirePermission(enum.CompaniesWrite), h.company.UpdateCompany)
+		companyRoutes.GET("/:id/users", auth.RequirePermission(enum.CompaniesRead, enum.UsersList), h.company.GetCompanyUsers)
+		companyRoutes.POST("/:id/users/:userId", auth.RequirePermission(enum.CompaniesWrite, enum.UsersManage), h.company.AddUser)
+		companyRoutes.DELETE("/:id/users/:userId", auth.RequirePermission(enum.CompaniesWrite, enum.UsersManage), h.company.RemoveUser)
+	}
+
+	//Post API's
+	r.POST("/post", auth.RequirePermission(enum.PostsWrite), h.post.CreatePost)
+	// Published posts can be read without signing in
+	r.GET("/getAllPosts/:id", auth.AllowAnonymous(enum.PostsRead), h.post.GetPosts)
+	r.GET("/getPost/:id", auth.AllowAnonymous(enum.PostsRead), h.post.GetPostById)
+	r.PUT("/post/:id", auth.RequirePermission(enum.PostsWrite), h.post.UpdatePost)
+	r.DELETE("/post/:id", auth.RequirePermission(enum.PostsDelete), h.post.DeletePost)
+
+	//Users API's
+	userRoutes := r.Group("/user")
+	{
+		userRoutes.POST("/", auth.RequirePermission(enum.UsersCreate), h.user.CreateUser)         // Create user
+		userRoutes.GET("/", auth.RequirePermission(enum.UsersList), h.user.GetUsers)              // Get all users
+		userRoutes.GET("/:id", auth.RequirePermission(enum.UsersRead), h.user.GetUserById)        // Get user by ID
+		userRoutes.PUT("/:id", auth.RequirePermission(enum.UsersWrite), h.user.UpdateUserDetails) // Update user details
+		userRoutes.PATCH("/:id", auth.RequirePermission(enum.UsersWrite), h.user.PatchUser)       // Patch user fields
+		userRoutes.DELETE("/:id", auth.RequirePermission(enum.UsersDelete), h.user.DeleteUser)    // Delete user
+		userRoutes.GET("/paginated", auth.RequirePermission(enum.UsersList), h.user.PaginateUsers)
+		r.POST("/login", h.user.LoginUser)
+		// Paginated users
+	}
+
+	//Token API's
+	r.POST("/token/refresh", h.token.RefreshToken)
+	r.POST("/logout", h.token.Logout)
+	r.GET("/.well-known/jwks.json", h.jwks.GetJWKS)
+
+	//Role API's
+	roleRoutes := r.Group("/roles")
+	{
+		roleRoutes.GET("/", auth.RequirePermission(enum.RolesRead), h.role.GetRoles)
+		roleRoutes.GET("/:name", auth.RequirePermission(enum.RolesRead), h.role.GetRole)
+		roleRoutes.POST("/", auth.RequirePermission(enum.RolesWrite), h.role.CreateRole)
+		roleRoutes.PUT("/:name", auth.RequirePermission(enum.RolesWrite), h.role.UpdateRole)
+		roleRoutes.DELETE("/:name", auth.RequirePermission(enum.RolesWrite), h.role.DeleteRole)
+	}
+	r.GET("/permissions", auth.RequirePermission(enum.RolesRead), h.role.GetPermissions)
+
+	//Trash API's
+	trashRoutes := r.Group("/admin/trash")
+	{
+		trashRoutes.GET("/:resource", auth.RequirePermission(enum.TrashManage), h.trash.GetTrash)
+		trashRoutes.POST("/:resource/:id/restore", auth.RequirePermission(enum.TrashManage), h.trash.Restore)
+	}
+
+	return r
+}
@@ -0,0 +1,109 @@
+// Command migrate manages the database schema:
+//
+//	go run ./cmd/migrate up          apply every pending migration
+//	go run ./cmd/migrate down [N]    revert the last N migrations, 1 by default
+//	go run ./cmd/migrate status      list migrations and when they were applied
+//	go run ./cmd/migrate create NAME write the scripts of a new migration
+//
+// It connects to the database of the APP_ENV profile, see the config package.
+// New migrations are written to MIGRATIONS_DIR, migrations/sql by default.
+package main
+
+import (
+	"context"
+	"fmt"
+	"golang-crud/initializers"
+	"golang-crud/migrations"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "create":
+		create(args)
+		return
+	case "up", "down", "status":
+	default:
+		usage()
+	}
+
+	migrationList, err := migrations.Load(migrations.Embedded())
+	if err != nil {
+		log.Fatal("Failed to load migrations: ", err)
+	}
+	db, err := initializers.ConnectToDB(initializers.LoadConfig().Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator := migrations.New(db, migrationList)
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		report("Applied", applied, err)
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				log.Fatal("down takes a positive number of migrations to revert")
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		report("Reverted", reverted, err)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-25s %s\n", appliedAt, status.Migration)
+		}
+	}
+}
+
+func create(args []string) {
+	if len(args) != 1 {
+		usage()
+	}
+	dir := os.Getenv("MIGRATIONS_DIR")
+	if dir == "" {
+		dir = "migrations/sql"
+	}
+
+	up, down, err := migrations.Create(dir, args[0], time.Now())
+	if err != nil {
+		log.Fatal("Failed to create migration: ", err)
+	}
+	fmt.Println("Created", up)
+	fmt.Println("Created", down)
+}
+
+func report(action string, done []migrations.Migration, err error) {
+	for _, migration := range done {
+		fmt.Println(action, migration)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(done) == 0 {
+		fmt.Println("Nothing to do")
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [N] | status | create NAME")
+	os.Exit(2)
+}
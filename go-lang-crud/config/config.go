@@ -0,0 +1,145 @@
+// Package config loads the application settings from defaults, an optional
+// YAML file with per-environment profiles, a .env file and the environment,
+// each overriding the one before, and validates them at startup.
+package config
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles
+const (
+	Dev  = "dev"
+	Test = "test"
+	Prod = "prod"
+)
+
+type Config struct {
+	// Env is the profile, read from APP_ENV only since it selects the file section
+	Env          string       `yaml:"env" validate:"oneof=dev test prod"`
+	HTTP         HTTP         `yaml:"http"`
+	Database     Database     `yaml:"database"`
+	JWT          JWT          `yaml:"jwt"`
+	Auth         Auth         `yaml:"auth"`
+	Provisioning Provisioning `yaml:"provisioning"`
+	Trash        Trash        `yaml:"trash"`
+	Health       Health       `yaml:"health"`
+}
+
+type HTTP struct {
+	Addr string `yaml:"addr" env:"HTTP_ADDR" validate:"required"`
+	// Timeouts of the server, zero means none, see http.Server
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" validate:"gte=0"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" validate:"gte=0"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" validate:"gte=0"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" validate:"gte=0"`
+	// ShutdownTimeout bounds draining in-flight requests and running the
+	// shutdown hooks once a stop signal is received
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" validate:"gt=0"`
+}
+
+type Database struct {
+	URL Secret `yaml:"url" env:"DB_URL" validate:"required"`
+	// AutoMigrate applies pending migrations at startup, off by default in
+	// prod where "go run ./cmd/migrate up" runs before deploying
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+	// LogQueries prints every SQL statement
+	LogQueries bool `yaml:"log_queries" env:"DB_LOG_QUERIES"`
+}
+
+type JWT struct {
+	// KeysDir holds the signing keys; without it an ephemeral key is
+	// generated, which the prod profile doesn't allow
+	KeysDir    string        `yaml:"keys_dir" env:"JWT_KEYS_DIR"`
+	ActiveKID  string        `yaml:"active_kid" env:"JWT_ACTIVE_KID"`
+	SigningAlg string        `yaml:"signing_alg" env:"JWT_SIGNING_ALG"`
+	Issuer     string        `yaml:"issuer" env:"JWT_ISSUER" validate:"required"`
+	Audience   []string      `yaml:"audience" env:"JWT_AUDIENCE" validate:"required"`
+	Leeway     time.Duration `yaml:"leeway" env:"JWT_LEEWAY" validate:"gte=0"`
+}
+
+// Auth lists the identity providers users can sign in with, see loadProviders
+// for their environment variables.
+type Auth struct {
+	Providers []Provider `yaml:"providers" validate:"dive"`
+	// SessionSecret signs and encrypts the cookie kept during a sign-in,
+	// it must be shared by every instance
+	SessionSecret Secret `yaml:"session_secret" env:"SESSION_SECRET"`
+}
+
+type Provider struct {
+	// Name is used in the /auth/:provider routes
+	Name string `yaml:"name" validate:"required"`
+	// Type selects the provider implementation, the name by default
+	Type         string   `yaml:"type" validate:"required"`
+	ClientID     string   `yaml:"client_id" validate:"required"`
+	ClientSecret Secret   `yaml:"client_secret" validate:"required"`
+	CallbackURL  string   `yaml:"callback_url" validate:"required,url"`
+	DiscoveryURL string   `yaml:"discovery_url" validate:"omitempty,url"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// Provisioning controls creating users on their first social login.
+type Provisioning struct {
+	Enabled     bool   `yaml:"enabled" env:"OAUTH_JIT_PROVISIONING"`
+	DefaultRole string `yaml:"default_role" env:"OAUTH_DEFAULT_ROLE" validate:"required"`
+	// DomainCompanies maps email domains to company IDs ("acme.com=1,example.org=2")
+	DomainCompanies map[string]uint `yaml:"domain_companies" env:"OAUTH_DOMAIN_COMPANIES"`
+	// DefaultCompanyID catches unmapped domains; zero refuses them
+	DefaultCompanyID uint `yaml:"default_company_id" env:"OAUTH_DEFAULT_COMPANY_ID"`
+	// TrustedProviders are the providers whose verified emails sign users in
+	// by email, linking them or provisioning new ones; accounts at other
+	// providers are linked from a signed-in session. Google is trusted by
+	// default, as existing Google users have always signed in by email
+	TrustedProviders []string `yaml:"trusted_providers" env:"OAUTH_TRUSTED_PROVIDERS"`
+}
+
+// Trash controls how long deleted records are kept before they are purged.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" validate:"gt=0"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" validate:"gt=0"`
+}
+
+// Health controls the readiness checks.
+type Health struct {
+	// Timeout bounds every check of a readiness probe
+	Timeout time.Duration `yaml:"timeout" env:"HEALTH_CHECK_TIMEOUT" validate:"gt=0"`
+}
+
+// Defaults returns the settings used for whatever isn't configured.
+func Defaults(profile string) Config {
+	return Config{
+		Env: profile,
+		HTTP: HTTP{
+			Addr:              ":8081",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: Database{AutoMigrate: profile != Prod, LogQueries: profile == Dev},
+		JWT: JWT{
+			Issuer:   "golang-crud",
+			Audience: []string{"golang-crud"},
+			Leeway:   30 * time.Second,
+		},
+		Provisioning: Provisioning{DefaultRole: "user", TrustedProviders: []string{"google"}},
+		Trash: Trash{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Health: Health{Timeout: 2 * time.Second},
+	}
+}
+
+// String renders the configuration as YAML with secrets redacted.
+func (c Config) String() string {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return string(out)
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when CONFIG_FILE is not set, if it exists.
+const DefaultFile = "config.yaml"
+
+// Load reads the configuration of the profile named by APP_ENV, dev by
+// default: the defaults, then CONFIG_FILE (config.yaml if present), then
+// .env and the environment, which wins over .env. It returns every problem
+// it finds at once, as an Error.
+func Load() (*Config, error) {
+	// Variables already in the environment are not overridden
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read .env: %w", err)
+	}
+
+	profile := os.Getenv("APP_ENV")
+	if profile == "" {
+		profile = Dev
+	}
+	config := Defaults(profile)
+
+	path, required := os.LookupEnv("CONFIG_FILE")
+	if !required {
+		path = DefaultFile
+	}
+	if err := config.applyFile(path, required); err != nil {
+		return nil, err
+	}
+
+	var problems Error
+	problems = append(problems, applyEnv(reflect.ValueOf(&config).Elem())...)
+	if providers := loadProviders(); providers != nil {
+		config.Auth.Providers = providers
+	} else {
+		loadProviderSecrets(config.Auth.Providers)
+	}
+	for i, provider := range config.Auth.Providers {
+		if provider.Type == "" {
+			config.Auth.Providers[i].Type = provider.Name
+		}
+	}
+	problems = append(problems, config.validate()...)
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return &config, nil
+}
+
+// file is the layout of the configuration file: settings shared by every
+// profile at the top level, and overrides under profiles.<name>.
+type file struct {
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+func (c *Config) applyFile(path string, required bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	// The profile was chosen by APP_ENV, the file can't switch it
+	profile := c.Env
+
+	var layout file
+	if err := yaml.Unmarshal(content, &layout); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(content, c); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if overrides, ok := layout.Profiles[profile]; ok {
+		if err := overrides.Decode(c); err != nil {
+			return fmt.Errorf("parse %s: profiles.%s: %w", path, profile, err)
+		}
+	}
+	c.Env = profile
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets every field tagged with env whose variable is set,
+// returning the variables it couldn't parse.
+func applyEnv(value reflect.Value) []string {
+	var problems []string
+	for i := 0; i < value.NumField(); i++ {
+		field, structField := value.Field(i), value.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			problems = append(problems, applyEnv(field)...)
+			continue
+		}
+
+		name := structField.Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		if err := setField(field, strings.TrimSpace(raw)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return problems
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		field.SetBool(value)
+	case reflect.Uint:
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a positive number", raw)
+		}
+		field.SetUint(value)
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(raw)))
+	case reflect.Map:
+		mapping, err := parseMapping(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(mapping))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// parseMapping reads "key=id" pairs separated by commas, keys lower-cased.
+func parseMapping(raw string) (map[string]uint, error) {
+	mapping := map[string]uint{}
+	for _, pair := range splitList(raw) {
+		key, id, found := strings.Cut(pair, "=")
+		value, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid entry %q, expected key=id", pair)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(key))] = uint(value)
+	}
+	return mapping, nil
+}
+
+// loadProviders reads the identity providers listed in AUTH_PROVIDERS, or
+// returns nil to keep those of the file. Each provider NAME is configured
+// with NAME_TYPE (defaults to the name), NAME_CLIENT_ID, NAME_CLIENT_SECRET,
+// NAME_CALLBACK_URL, NAME_SCOPES and, for OpenID Connect, NAME_DISCOVERY_URL.
+//
+// Without AUTH_PROVIDERS the legacy CLIENT_ID, CLIENT_SECRET and
+// Callback_URL variables configure Google.
+func loadProviders() []Provider {
+	names := os.Getenv("AUTH_PROVIDERS")
+	if names == "" {
+		if os.Getenv("CLIENT_ID") == "" {
+			return nil
+		}
+		return []Provider{{
+			Name:         "google",
+			Type:         "google",
+			ClientID:     os.Getenv("CLIENT_ID"),
+			ClientSecret: Secret(os.Getenv("CLIENT_SECRET")),
+			CallbackURL:  os.Getenv("Callback_URL"),
+		}}
+	}
+
+	var providers []Provider
+	for _, name := range splitList(names) {
+		name = strings.ToLower(name)
+		prefix := envPrefix(name)
+
+		providerType := os.Getenv(prefix + "TYPE")
+		if providerType == "" {
+			providerType = name
+		}
+
+		providers = append(providers, Provider{
+			Name:         name,
+			Type:         strings.ToLower(providerType),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: Secret(os.Getenv(prefix + "CLIENT_SECRET")),
+			CallbackURL:  os.Getenv(prefix + "CALLBACK_URL"),
+			DiscoveryURL: os.Getenv(prefix + "DISCOVERY_URL"),
+			Scopes:       splitList(os.Getenv(prefix + "SCOPES")),
+		})
+	}
+	return providers
+}
+
+// loadProviderSecrets reads NAME_CLIENT_SECRET for the providers of the file,
+// so their secrets don't have to be written in it.
+func loadProviderSecrets(providers []Provider) {
+	for i := range providers {
+		if secret := os.Getenv(envPrefix(providers[i].Name) + "CLIENT_SECRET"); secret != "" {
+			providers[i].ClientSecret = Secret(secret)
+		}
+	}
+}
+
+func envPrefix(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,32 @@
+package config
+
+import "encoding/json"
+
+const redacted = "[REDACTED]"
+
+// Secret is a setting that must never be printed, such as a password or a
+// connection string embedding one. Use Value to read it.
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
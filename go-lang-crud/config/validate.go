@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Error lists every problem found in the configuration.
+type Error []string
+
+func (e Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// validate checks the validate tags, reporting settings by their path in the
+// configuration file, and the rules that depend on the profile.
+func (c *Config) validate() []string {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("yaml"), ",", 2)[0]
+	})
+
+	var problems []string
+	if err := validate.Struct(c); err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			return []string{err.Error()}
+		}
+		for _, fieldError := range fieldErrors {
+			path := strings.TrimPrefix(fieldError.Namespace(), "Config.")
+			problems = append(problems, path+" "+message(fieldError))
+		}
+	}
+
+	if len(c.Auth.Providers) > 0 && c.Auth.SessionSecret == "" {
+		problems = append(problems, "auth.session_secret is required with identity providers")
+	}
+	if c.Env == Prod && c.JWT.KeysDir == "" {
+		problems = append(problems, "jwt.keys_dir is required in prod, ephemeral signing keys would log everyone out on restart")
+	}
+	return problems
+}
+
+func message(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	case "url":
+		return "must be a URL"
+	case "gt":
+		return "must be greater than " + fieldError.Param()
+	case "gte":
+		return "must be at least " + fieldError.Param()
+	}
+	return fmt.Sprintf("failed the %s rule", fieldError.Tag())
+}
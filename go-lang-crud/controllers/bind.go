@@ -0,0 +1,83 @@
+// controllers/bind.go
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"golang-crud/custom_error"
+	"golang-crud/validation"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON binds the request body and validates it, reporting 400 for a
+// malformed body and 422 listing every invalid field otherwise.
+func bindJSON(c *gin.Context, validator *validation.Validator, request interface{}) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
+		if fieldErrors, ok := decodeErrors(err); ok {
+			respondInvalid(c, fieldErrors)
+			return false
+		}
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "Malformed JSON body", err))
+		return false
+	}
+	return validate(c, validator, request)
+}
+
+// bindPatched decodes a patched document and validates it like bindJSON,
+// also reporting 422 for members the request doesn't have.
+func bindPatched(c *gin.Context, validator *validation.Validator, document []byte, request interface{}) bool {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		if fieldErrors, ok := decodeErrors(err); ok {
+			respondInvalid(c, fieldErrors)
+			return false
+		}
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "Malformed patched document", err))
+		return false
+	}
+	return validate(c, validator, request)
+}
+
+func validate(c *gin.Context, validator *validation.Validator, request interface{}) bool {
+	if err := validator.Struct(c.Request.Context(), request); err != nil {
+		var fieldErrors validation.Errors
+		if errors.As(err, &fieldErrors) {
+			respondInvalid(c, fieldErrors)
+			return false
+		}
+		c.Error(err)
+		return false
+	}
+	return true
+}
+
+// decodeErrors describes JSON decoding errors caused by a field: a value of
+// the wrong type or, with DisallowUnknownFields, an unknown field.
+func decodeErrors(err error) (validation.Errors, bool) {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return validation.Errors{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: "must be a " + typeError.Type.String(),
+		}}, true
+	}
+
+	// encoding/json has no error type for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return validation.Errors{{
+			Field:   strings.Trim(field, `"`),
+			Rule:    "unknown",
+			Message: "is not a known field",
+		}}, true
+	}
+	return nil, false
+}
+
+func respondInvalid(c *gin.Context, fieldErrors validation.Errors) {
+	c.Error(custom_error.Validation("Validation failed", fieldErrors))
+}
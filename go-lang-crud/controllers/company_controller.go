@@ -2,53 +2,152 @@
 package controllers
 
 import (
-	"golang-crud/models"
+	"golang-crud/dto"
+	"golang-crud/pagination"
+	"golang-crud/repository"
 	"golang-crud/service"
+	"golang-crud/validation"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CompanyController struct {
 	companyService service.CompanyService // Not a pointer
+	validator      *validation.Validator
 }
 
-func NewCompanyController(companyService service.CompanyService) *CompanyController {
-	return &CompanyController{companyService: companyService}
+func NewCompanyController(companyService service.CompanyService, validator *validation.Validator) *CompanyController {
+	return &CompanyController{companyService: companyService, validator: validator}
 }
 
 func (cc *CompanyController) CreateCompany(c *gin.Context) {
-	var company models.Company
-	if err := c.ShouldBindJSON(&company); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	var request dto.CreateCompanyRequest
+	if !bindJSON(c, cc.validator, &request) {
 		return
 	}
+	company := request.Model()
 
-	if err := cc.companyService.CreateCompany(&company); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create company"})
+	if err := cc.companyService.CreateCompany(c.Request.Context(), company); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(201, gin.H{"message": "Company created successfully", "company": company})
+	c.JSON(201, gin.H{"message": "Company created successfully", "company": dto.NewCompanyResponse(*company)})
 }
 
 func (cc *CompanyController) GetAllCompanies(c *gin.Context) {
-	companies, err := cc.companyService.GetAllCompanies()
+	query, selection, params, ok := parseListQuery(c, repository.CompanyQuery, repository.CompanyFields)
+	if !ok {
+		return
+	}
+
+	page, err := cc.companyService.GetAllCompanies(c.Request.Context(), query, selection, params)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondPage(c, pagination.Map(page, dto.NewCompanyResponse), selection)
+}
+
+func (cc *CompanyController) GetCompanyById(c *gin.Context) {
+	selection, ok := parseSelection(c, repository.CompanyFields)
+	if !ok {
+		return
+	}
+
+	company, err := cc.companyService.GetCompanyById(c.Request.Context(), c.Param("id"), selection)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to retrieve companies"})
+		c.Error(err)
+		return
+	}
+	if notModified(c, company.Version, selection) {
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"companies": companies,
-	})
+	respondProjected(c, "company", dto.NewCompanyResponse(*company), selection)
+}
+
+// UpdateCompany handles both PUT, which requires every field, and PATCH,
+// which only changes the fields present in the body
+func (cc *CompanyController) UpdateCompany(c *gin.Context) {
+	var request dto.UpdateCompanyRequest
+	if !bindJSON(c, cc.validator, &request) {
+		return
+	}
+
+	data := map[string]interface{}{}
+	if request.Name != nil {
+		data["name"] = *request.Name
+	} else if c.Request.Method == http.MethodPut {
+		respondInvalid(c, validation.Errors{{Field: "name", Rule: "required", Message: "is required"}})
+		return
+	}
+
+	company, err := cc.companyService.GetCompanyById(c.Request.Context(), c.Param("id"), nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !checkIfMatch(c, company.Version) {
+		return
+	}
+
+	company, err = cc.companyService.UpdateCompany(c.Request.Context(), c.Param("id"), company.Version, data)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, company.Version)
+
+	c.JSON(200, gin.H{"message": "Company updated successfully", "company": dto.NewCompanyResponse(*company)})
 }
 
 func (cc *CompanyController) DeleteCompany(c *gin.Context) {
 	id := c.Param("id")
-	if err := cc.companyService.DeleteCompany(id); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete company"})
+	company, err := cc.companyService.GetCompanyById(c.Request.Context(), id, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !checkIfMatch(c, company.Version) {
+		return
+	}
+
+	if err := cc.companyService.DeleteCompany(c.Request.Context(), id, company.Version); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Company deleted successfully"})
 }
+
+func (cc *CompanyController) GetCompanyUsers(c *gin.Context) {
+	users, err := cc.companyService.GetCompanyUsers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"users": dto.NewUserResponses(users)})
+}
+
+func (cc *CompanyController) AddUser(c *gin.Context) {
+	if err := cc.companyService.AddUserToCompany(c.Request.Context(), c.Param("id"), c.Param("userId")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User added to company successfully"})
+}
+
+func (cc *CompanyController) RemoveUser(c *gin.Context) {
+	if err := cc.companyService.RemoveUserFromCompany(c.Request.Context(), c.Param("id"), c.Param("userId")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User removed from company successfully"})
+}
@@ -2,69 +2,159 @@
 package controllers
 
 import (
-	"golang-crud/security"
+	"errors"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/dto"
+	"golang-crud/middlewares"
+	"golang-crud/oauth"
 	"golang-crud/service"
+	"html"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/markbates/goth/gothic"
 )
 
 type GoAuthController struct {
-	userService service.UserService
+	identityService *service.IdentityService
+	tokenService    *service.TokenService
+	providers       *oauth.Registry
 }
 
-func NewGoAuthController(userService service.UserService) *GoAuthController {
-	return &GoAuthController{userService: userService}
+func NewGoAuthController(identityService *service.IdentityService, tokenService *service.TokenService, providers *oauth.Registry) *GoAuthController {
+	return &GoAuthController{identityService: identityService, tokenService: tokenService, providers: providers}
 }
 
 func (uc *GoAuthController) HandleHome(c *gin.Context) {
-	html := `<html><body><a href="/login">Google Login</a></body></html>`
-	c.Data(200, "text/html; charset=utf-8", []byte(html))
+	var links strings.Builder
+	for _, name := range uc.providers.Names() {
+		name = html.EscapeString(name)
+		fmt.Fprintf(&links, `<a href="/auth/%s/login">Login with %s</a><br>`, name, name)
+	}
+	page := `<html><body>` + links.String() + `</body></html>`
+	c.Data(200, "text/html; charset=utf-8", []byte(page))
+}
+
+// withProvider returns the provider named in the route, or reports 404 if
+// no such provider is enabled. Only the route is trusted, query parameters
+// can't switch the flow to another provider.
+func (uc *GoAuthController) withProvider(c *gin.Context) (string, bool) {
+	provider := c.Param("provider")
+	if _, ok := uc.providers.Get(provider); !ok {
+		c.Error(custom_error.NotFound("Unknown identity provider: " + provider))
+		return "", false
+	}
+	return provider, true
 }
 
 func (uc *GoAuthController) SignInWithProvider(c *gin.Context) {
-	provider := "google"
-	q := c.Request.URL.Query()
-	q.Add("provider", provider)
-	c.Request.URL.RawQuery = q.Encode()
-	gothic.BeginAuthHandler(c.Writer, c.Request)
+	provider, ok := uc.withProvider(c)
+	if !ok {
+		return
+	}
+	authURL, err := uc.providers.Begin(c.Writer, c.Request, provider, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+// linkUserKey carries the signed-in user through a link flow.
+const linkUserKey = "link_user"
+
+// LinkProvider - Starts linking an account at the provider to the signed-in
+// user, answering with the URL to send the browser to. The flow cookie set
+// here must come back with the callback.
+func (uc *GoAuthController) LinkProvider(c *gin.Context) {
+	provider, ok := uc.withProvider(c)
+	if !ok {
+		return
+	}
+	principal, ok := middlewares.CurrentPrincipal(c)
+	if !ok {
+		c.Error(custom_error.Unauthorized("Sign in to link an account"))
+		return
+	}
+
+	values := map[string]string{linkUserKey: strconv.FormatUint(uint64(principal.User.ID), 10)}
+	authURL, err := uc.providers.Begin(c.Writer, c.Request, provider, values)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
 }
 
-// CallbackHandler processes the authentication response from Google.
+// CallbackHandler processes the authentication response from the identity provider.
 func (uc *GoAuthController) CallbackHandler(c *gin.Context) {
-	provider := "google"
-	q := c.Request.URL.Query()
-	q.Add("provider", provider)
-	c.Request.URL.RawQuery = q.Encode()
+	provider, ok := uc.withProvider(c)
+	if !ok {
+		return
+	}
 
-	user, err := gothic.CompleteUserAuth(c.Writer, c.Request)
+	user, values, err := uc.providers.Complete(c.Writer, c.Request, provider)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.Error(err)
+		return
+	}
+	account := service.ExternalAccount{
+		Provider:      user.Provider,
+		Subject:       user.UserID,
+		Email:         user.Email,
+		EmailVerified: oauth.EmailVerified(user),
+		Name:          user.Name,
+	}
+
+	// Finish a link started by LinkProvider
+	if linkUser, ok := values[linkUserKey]; ok {
+		userID, err := strconv.ParseUint(linkUser, 10, 64)
+		if err != nil {
+			c.Error(oauth.ErrInvalidFlow)
+			return
+		}
+		linked, err := uc.identityService.LinkAccount(c.Request.Context(), uint(userID), account)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Account linked",
+			"user":    dto.NewUserResponse(*linked),
+		})
 		return
 	}
 
-	// Access the user repository to manage users
-	// userRepository := repository.NewUserRepository(initializers.DB)
-	userData, err := uc.userService.GetUserByEmail(user.Email)
+	// Find the linked user, linking or provisioning one on first sign-in
+	userData, err := uc.identityService.ResolveUser(c.Request.Context(), account)
 
 	if err != nil {
-		// User does not exist, handle accordingly
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User doesn't exist with email: " + user.Email})
+		if errors.Is(err, custom_error.ErrProvisioningDenied) {
+			c.Error(custom_error.Wrap(custom_error.KindForbidden, "This account can't sign in until it is linked to a user, sign in and link it first", err))
+			return
+		}
+		if errors.Is(err, custom_error.ErrUserNotFound) {
+			// User does not exist and can't be provisioned, handle accordingly
+			c.Error(custom_error.Wrap(custom_error.KindBadRequest, "User doesn't exist with email: "+user.Email, err))
+			return
+		}
+		c.Error(err)
 		return
 	}
 
-	// Generate JWT token for the user
-	tokenString, err := security.GenerateJWT(userData)
+	// Generate access and refresh tokens for the user
+	tokens, err := uc.tokenService.IssueTokens(userData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Error generating token"})
+		c.Error(err)
 		return
 	}
 
 	// // Optionally, return the token to the frontend or store it in session/local storage
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Authentication successful",
-		"user":    userData,
-		"token":   tokenString,
+		"user":    dto.NewUserResponse(*userData),
+		"tokens":  tokens,
 	})
 }
@@ -0,0 +1,43 @@
+// controllers/health_controller.go
+package controllers
+
+import (
+	"golang-crud/health"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	readiness *health.Health
+}
+
+func NewHealthController(readiness *health.Health) *HealthController {
+	return &HealthController{readiness: readiness}
+}
+
+// Liveness - Answers as long as the process serves requests; dependencies
+// are left to Readiness so an outage doesn't get every replica restarted
+func (hc *HealthController) Liveness(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, health.Report{Status: health.Up})
+}
+
+// Readiness - Reports every dependency, with 503 if any is down so the
+// instance is taken out of the load balancer
+func (hc *HealthController) Readiness(c *gin.Context) {
+	report := hc.readiness.Check(c.Request.Context())
+	for name, component := range report.Components {
+		if component.Err != nil {
+			log.Println("Health check failed : ", name, component.Err)
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status != health.Up {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
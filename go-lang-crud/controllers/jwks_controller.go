@@ -0,0 +1,23 @@
+// controllers/jwks_controller.go
+package controllers
+
+import (
+	"golang-crud/security"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct {
+	keyManager *security.KeyManager
+}
+
+func NewJWKSController(keyManager *security.KeyManager) *JWKSController {
+	return &JWKSController{keyManager: keyManager}
+}
+
+// GetJWKS - Publishes the public keys other services verify our tokens with
+func (jc *JWKSController) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jc.keyManager.JWKS())
+}
@@ -0,0 +1,91 @@
+// controllers/list_query.go
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
+	"golang-crud/middlewares"
+	"golang-crud/pagination"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseListQuery reads the filter, sort, fieldset and pagination parameters
+// of a list request, reporting 400 when the resource doesn't allow them.
+func parseListQuery(c *gin.Context, resource filter.Resource, fields fieldset.Resource) (*filter.Query, *fieldset.Selection, pagination.Params, bool) {
+	values := c.Request.URL.Query()
+
+	query, err := resource.Parse(values)
+	if err != nil {
+		c.Error(err)
+		return nil, nil, pagination.Params{}, false
+	}
+
+	selection, ok := parseSelection(c, fields)
+	if !ok {
+		return nil, nil, pagination.Params{}, false
+	}
+
+	params, err := pagination.ParseQuery(values)
+	if err != nil {
+		c.Error(err)
+		return nil, nil, pagination.Params{}, false
+	}
+
+	return query, selection, params, true
+}
+
+// parseSelection reads the fields and include parameters, reporting 400 when
+// the resource doesn't expose them and 403 when the authenticated user lacks
+// the permission an include requires.
+func parseSelection(c *gin.Context, fields fieldset.Resource) (*fieldset.Selection, bool) {
+	selection, err := fields.Parse(c.Request.URL.Query())
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	principal, ok := middlewares.CurrentPrincipal(c)
+	for _, permission := range selection.Permissions() {
+		if !ok {
+			c.Error(custom_error.Unauthorized("Sign in to include related records"))
+			return nil, false
+		}
+		if !principal.Can(permission) {
+			c.Error(custom_error.Forbidden("Including these records requires " + string(permission)))
+			return nil, false
+		}
+	}
+	return selection, true
+}
+
+// respondProjected answers with the record under key, limited to the selection.
+func respondProjected(c *gin.Context, key string, record interface{}, selection *fieldset.Selection) {
+	projected, err := selection.Project(record)
+	if err != nil {
+		c.Error(fmt.Errorf("render %s: %w", key, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{key: projected})
+}
+
+// respondPage answers with the page envelope, each item limited to the selection.
+func respondPage[T any](c *gin.Context, page *pagination.Page[T], selection *fieldset.Selection) {
+	var err error
+	projected := pagination.Map(page, func(item T) map[string]json.RawMessage {
+		fields, projectErr := selection.Project(item)
+		if projectErr != nil {
+			err = projectErr
+		}
+		return fields
+	})
+	if err != nil {
+		c.Error(fmt.Errorf("render page: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, projected.Envelope(c.Request.URL))
+}
@@ -2,55 +2,181 @@
 package controllers
 
 import (
+	"errors"
+	"golang-crud/custom_error"
+	"golang-crud/dto"
+	"golang-crud/fieldset"
+	"golang-crud/middlewares"
 	"golang-crud/models"
+	"golang-crud/pagination"
+	"golang-crud/policy"
+	"golang-crud/repository"
 	"golang-crud/service"
+	"golang-crud/validation"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PostController struct {
 	postService *service.PostService
+	validator   *validation.Validator
 }
 
-func NewPostController(postService *service.PostService) *PostController {
-	return &PostController{postService: postService}
+func NewPostController(postService *service.PostService, validator *validation.Validator) *PostController {
+	return &PostController{postService: postService, validator: validator}
 }
 
 func (pc *PostController) CreatePost(c *gin.Context) {
-	var post models.Post
-	if err := c.ShouldBindJSON(&post); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	var request dto.CreatePostRequest
+	if !bindJSON(c, pc.validator, &request) {
 		return
 	}
+	post := request.Model()
 
-	if err := pc.postService.CreatePost(&post); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create post"})
+	principal, ok := middlewares.CurrentPrincipal(c)
+	if !ok {
+		c.Error(custom_error.Unauthorized("Authentication required"))
 		return
 	}
 
-	c.JSON(201, gin.H{"message": "Post created successfully", "post": post})
+	// Posts are written as the current user unless they may post for others
+	if post.UserId == 0 {
+		post.UserId = principal.User.ID
+	}
+	if !policy.CanActAsAuthor(principal, post.UserId) {
+		c.Error(custom_error.Forbidden("You can only create posts as yourself"))
+		return
+	}
+
+	if err := pc.postService.CreatePost(c.Request.Context(), post); err != nil {
+		if errors.Is(err, custom_error.ErrUserNotFound) {
+			c.Error(custom_error.BadRequest("Author not found"))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(201, gin.H{"message": "Post created successfully", "post": dto.NewPostResponse(*post)})
 }
 
+// GetPosts lists a user's posts. Only the author (or a posts:manage holder)
+// sees drafts, archived and scheduled posts.
 func (pc *PostController) GetPosts(c *gin.Context) {
 	uid := c.Param("id")
-	posts, err := pc.postService.GetPostsByUserId(uid)
+
+	includeUnpublished := false
+	if principal, ok := middlewares.CurrentPrincipal(c); ok {
+		if authorId, err := strconv.ParseUint(uid, 10, 64); err == nil {
+			includeUnpublished = policy.CanActAsAuthor(principal, uint(authorId))
+		}
+	}
+
+	query, selection, params, ok := parseListQuery(c, repository.PostQuery, repository.PostFields)
+	if !ok || !publicSelection(c, selection) {
+		return
+	}
+
+	page, err := pc.postService.GetPostsByUserId(c.Request.Context(), uid, includeUnpublished, query, selection, params)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to retrieve posts"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"posts": posts,
-	})
+	respondPage(c, pagination.Map(page, dto.NewPostResponse), selection)
 }
 
 func (pc *PostController) GetPostById(c *gin.Context) {
 	id := c.Param("id")
-	post, err := pc.postService.GetPostById(id)
+	selection, ok := parseSelection(c, repository.PostFields)
+	if !ok || !publicSelection(c, selection) {
+		return
+	}
+
+	post, err := pc.postService.GetPostById(c.Request.Context(), id, selection)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Post not found"})
+		c.Error(err)
+		return
+	}
+	if !canView(c, post) {
+		c.Error(custom_error.ErrPostNotFound)
+		return
+	}
+
+	respondProjected(c, "post", dto.NewPostResponse(*post), selection)
+}
+
+func (pc *PostController) UpdatePost(c *gin.Context) {
+	var request dto.UpdatePostRequest
+	if !bindJSON(c, pc.validator, &request) {
+		return
+	}
+
+	post, ok := pc.findModifiable(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.postService.UpdatePost(c.Request.Context(), post, request.Changes()); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(200, gin.H{"post": post})
+	c.JSON(200, gin.H{"message": "Post updated successfully", "post": dto.NewPostResponse(*post)})
+}
+
+func (pc *PostController) DeletePost(c *gin.Context) {
+	post, ok := pc.findModifiable(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.postService.DeletePost(c.Request.Context(), post); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Post deleted successfully"})
+}
+
+// findModifiable loads the post in the route and checks the current user may
+// edit it, reporting 404 or 403 otherwise
+func (pc *PostController) findModifiable(c *gin.Context) (*models.Post, bool) {
+	post, err := pc.postService.GetPostById(c.Request.Context(), c.Param("id"), nil)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	if !canView(c, post) {
+		c.Error(custom_error.ErrPostNotFound)
+		return nil, false
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	if !policy.CanModifyPost(principal, post) {
+		c.Error(custom_error.Forbidden("You can only modify your own posts"))
+		return nil, false
+	}
+	return post, true
+}
+
+// publicSelection refuses includes to anonymous readers: the authors of
+// published posts aren't public.
+func publicSelection(c *gin.Context, selection *fieldset.Selection) bool {
+	if _, ok := middlewares.CurrentPrincipal(c); !ok && selection.HasIncludes() {
+		c.Error(custom_error.Unauthorized("Sign in to include related records"))
+		return false
+	}
+	return true
+}
+
+// canView hides unpublished posts from everyone but their author
+func canView(c *gin.Context, post *models.Post) bool {
+	if post.IsVisible(time.Now()) {
+		return true
+	}
+	principal, ok := middlewares.CurrentPrincipal(c)
+	return ok && policy.CanModifyPost(principal, post)
 }
@@ -0,0 +1,89 @@
+// controllers/precondition.go
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"golang-crud/custom_error"
+	"golang-crud/fieldset"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the entity tag of a resource at the given version.
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// selectionETag is the entity tag of the representation selection renders
+// of a resource at the given version. Only the full representation has the
+// plain tag checkIfMatch accepts.
+func selectionETag(version uint, selection *fieldset.Selection) string {
+	key := selection.Key()
+	if key == "" {
+		return etag(version)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return `"` + strconv.FormatUint(uint64(version), 10) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// notModified sets the ETag of the representation and answers 304 if it
+// matches If-None-Match, in which case the caller must not write a body.
+// Responses with includes get no ETag: the included records change without
+// the version of the resource changing.
+func notModified(c *gin.Context, version uint, selection *fieldset.Selection) bool {
+	if selection.HasIncludes() {
+		return false
+	}
+	tag := selectionETag(version, selection)
+	c.Header("ETag", tag)
+
+	if header := c.GetHeader("If-None-Match"); header != "" && matchesETag(header, tag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// checkIfMatch requires the request to name the version of the resource it
+// modifies in If-Match, reporting 428 if it doesn't and 412 if the resource
+// has changed since.
+func checkIfMatch(c *gin.Context, version uint) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.Error(custom_error.ErrPreconditionRequired)
+		return false
+	}
+	if !matchesETag(header, etag(version), false) {
+		c.Error(custom_error.ErrPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// matchesETag reports whether a list of entity tags from If-Match or
+// If-None-Match contains tag or is "*". Weak tags only match with weak
+// comparison, as used by If-None-Match.
+func matchesETag(header, tag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// setETag sets the ETag of the resource a successful update returns.
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", etag(version))
+}
@@ -0,0 +1,107 @@
+// controllers/role_controller.go
+package controllers
+
+import (
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/service"
+	"golang-crud/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleController struct {
+	roleService *service.RoleService
+}
+
+func NewRoleController(roleService *service.RoleService) *RoleController {
+	return &RoleController{roleService: roleService}
+}
+
+type roleRequest struct {
+	Name        enum.Role         `json:"name"`
+	Description string            `json:"description"`
+	Permissions []enum.Permission `json:"permissions"`
+}
+
+// GetRoles - Lists every role with its permissions
+func (rc *RoleController) GetRoles(c *gin.Context) {
+	roles, err := rc.roleService.GetRoles()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// GetRole - Returns a role by name
+func (rc *RoleController) GetRole(c *gin.Context) {
+	role, err := rc.roleService.GetRole(enum.Role(c.Param("name")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role})
+}
+
+// GetPermissions - Lists every permission roles can be granted
+func (rc *RoleController) GetPermissions(c *gin.Context) {
+	permissions, err := rc.roleService.GetPermissions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
+
+// CreateRole - Creates a role from a set of permissions
+func (rc *RoleController) CreateRole(c *gin.Context) {
+	var request roleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "Malformed JSON body", err))
+		return
+	}
+	if request.Name == "" {
+		c.Error(custom_error.Validation("Validation failed", validation.Errors{{Field: "name", Rule: "required", Message: "is required"}}))
+		return
+	}
+
+	role, err := rc.roleService.CreateRole(request.Name, request.Description, request.Permissions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Role created successfully", "role": role})
+}
+
+// UpdateRole - Replaces the description and permissions of a role
+func (rc *RoleController) UpdateRole(c *gin.Context) {
+	var request roleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "Malformed JSON body", err))
+		return
+	}
+
+	role, err := rc.roleService.UpdateRole(enum.Role(c.Param("name")), request.Description, request.Permissions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully", "role": role})
+}
+
+// DeleteRole - Deletes a role that no user is assigned to
+func (rc *RoleController) DeleteRole(c *gin.Context) {
+	if err := rc.roleService.DeleteRole(enum.Role(c.Param("name"))); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
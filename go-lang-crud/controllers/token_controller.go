@@ -0,0 +1,55 @@
+// controllers/token_controller.go
+package controllers
+
+import (
+	"golang-crud/custom_error"
+	"golang-crud/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenController struct {
+	tokenService *service.TokenService
+}
+
+func NewTokenController(tokenService *service.TokenService) *TokenController {
+	return &TokenController{tokenService: tokenService}
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken - Exchanges a refresh token for a new access/refresh token pair
+func (tc *TokenController) RefreshToken(c *gin.Context) {
+	var request refreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "refresh_token is required", err))
+		return
+	}
+
+	tokens, err := tc.tokenService.RefreshTokens(c.Request.Context(), request.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout - Revokes every token issued for the refresh token's session
+func (tc *TokenController) Logout(c *gin.Context) {
+	var request refreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "refresh_token is required", err))
+		return
+	}
+
+	if err := tc.tokenService.RevokeTokens(request.RefreshToken); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
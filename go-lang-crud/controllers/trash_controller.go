@@ -0,0 +1,63 @@
+// controllers/trash_controller.go
+package controllers
+
+import (
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/dto"
+	"golang-crud/models"
+	"golang-crud/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errUnknownResource = custom_error.NotFound("Unknown resource")
+
+type TrashController struct {
+	trashService *service.TrashService
+}
+
+func NewTrashController(trashService *service.TrashService) *TrashController {
+	return &TrashController{trashService: trashService}
+}
+
+// GetTrash - Lists the deleted users, posts or companies
+func (tc *TrashController) GetTrash(c *gin.Context) {
+	resource := service.TrashResource(c.Param("resource"))
+	records, ok, err := tc.trashService.GetDeleted(c.Request.Context(), resource)
+	if !ok {
+		c.Error(errUnknownResource)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	switch records := records.(type) {
+	case []models.User:
+		c.JSON(http.StatusOK, gin.H{string(resource): dto.NewUserResponses(records)})
+	case []models.Post:
+		c.JSON(http.StatusOK, gin.H{string(resource): dto.NewPostResponses(records)})
+	case []models.Company:
+		c.JSON(http.StatusOK, gin.H{string(resource): dto.NewCompanyResponses(records)})
+	default:
+		c.Error(fmt.Errorf("list trashed %s: unexpected records %T", resource, records))
+	}
+}
+
+// Restore - Restores a deleted record and the records deleted along with it
+func (tc *TrashController) Restore(c *gin.Context) {
+	ok, err := tc.trashService.Restore(c.Request.Context(), service.TrashResource(c.Param("resource")), c.Param("id"))
+	if !ok {
+		c.Error(errUnknownResource)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Record restored successfully"})
+}
@@ -2,78 +2,107 @@
 package controllers
 
 import (
+	"encoding/json"
+	"golang-crud/custom_error"
+	"golang-crud/dto"
+	"golang-crud/enum"
+	"golang-crud/middlewares"
 	"golang-crud/models"
+	"golang-crud/pagination"
+	"golang-crud/patch"
+	"golang-crud/policy"
+	"golang-crud/repository"
 	"golang-crud/service"
+	"golang-crud/validation"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserController struct {
-	userService service.UserService
+	userService  service.UserService
+	tokenService *service.TokenService
+	roleService  *service.RoleService
+	validator    *validation.Validator
 }
 
-func NewUserController(userService service.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService service.UserService, tokenService *service.TokenService, roleService *service.RoleService, validator *validation.Validator) *UserController {
+	return &UserController{userService: userService, tokenService: tokenService, roleService: roleService, validator: validator}
 }
 
 // CreateUser - Calls the CreateUser method in the service
 func (uc *UserController) CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.CreateUserRequest
+	if !bindJSON(c, uc.validator, &request) {
 		return
 	}
+	user := request.Model()
 
-	createdUser, err := uc.userService.CreateUser(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if user.Role == "" {
+		user.Role = enum.User
+	}
+	if !uc.canAssignRole(c, user.Role) {
+		c.Error(custom_error.Forbidden("You cannot assign the role " + string(user.Role)))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully", "user": createdUser})
-}
-
-// GetUsers - Calls GetAllUsers method in the service
-func (uc *UserController) GetUsers(c *gin.Context) {
-	users, err := uc.userService.GetAllUsers()
+	createdUser, err := uc.userService.CreateUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": users})
+	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully", "user": dto.NewUserResponse(*createdUser)})
 }
 
-// GetUserById - Calls the GetUserById method in the service
+// GetUsers - Lists users a page at a time, see PaginateUsers
+func (uc *UserController) GetUsers(c *gin.Context) {
+	uc.PaginateUsers(c)
+}
+
+// GetUserById - Calls the GetUserById method in the service, limited to the
+// fields and includes in the query string
 func (uc *UserController) GetUserById(c *gin.Context) {
 	id := c.Param("id")
 
-	user, err := uc.userService.GetUserById(id)
-	if err != nil || user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	selection, ok := parseSelection(c, repository.UserFields)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	user, err := uc.userService.GetUserById(c.Request.Context(), id, selection)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if notModified(c, user.Version, selection) {
+		return
+	}
+
+	respondProjected(c, "user", dto.NewUserResponse(*user), selection)
 }
 
 // UpdateUserDetails - Calls the UpdateUserDetails method in the service
 func (uc *UserController) UpdateUserDetails(c *gin.Context) {
-	var userRequest struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-	}
+	var userRequest dto.UpdateUserRequest
 	userId := c.Param("id")
 
-	if err := c.ShouldBindJSON(&userRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	user, err := uc.userService.GetUserById(c.Request.Context(), userId, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !uc.canUpdate(c, user) {
+		c.Error(custom_error.Forbidden("You can only update your own profile"))
+		return
+	}
+	if !checkIfMatch(c, user.Version) {
 		return
 	}
 
-	user, err := uc.userService.GetUserById(userId)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	// Users may keep their own email
+	c.Request = c.Request.WithContext(validation.ExcludingUser(c.Request.Context(), user.ID))
+	if !bindJSON(c, uc.validator, &userRequest) {
 		return
 	}
 
@@ -82,53 +111,155 @@ func (uc *UserController) UpdateUserDetails(c *gin.Context) {
 		"email": userRequest.Email,
 	}
 
-	if err := uc.userService.UpdateUserDetails(user, data); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	if err := uc.userService.UpdateUserDetails(c.Request.Context(), user, data); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "user": user})
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "user": dto.NewUserResponse(*user)})
 }
 
-// DeleteUser - Calls the DeleteUser method in the service
-func (uc *UserController) DeleteUser(c *gin.Context) {
-	id := c.Param("id")
+// PatchUser - Applies a JSON merge patch (application/merge-patch+json) or a
+// JSON patch (application/json-patch+json) to the user, changing only the
+// fields the patch touches. Users may change their own name, email and
+// password; see policy.CanPatchUserField for the rest.
+func (uc *UserController) PatchUser(c *gin.Context) {
+	userId := c.Param("id")
 
-	if err := uc.userService.DeleteUser(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+	user, err := uc.userService.GetUserById(c.Request.Context(), userId, nil)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	if !uc.canUpdate(c, user) {
+		c.Error(custom_error.Forbidden("You can only update your own profile"))
+		return
+	}
+	principal, _ := middlewares.CurrentPrincipal(c)
+	role, _ := uc.roleService.GetRole(user.Role)
 
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+	if !checkIfMatch(c, user.Version) {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	before, err := json.Marshal(dto.NewUserPatch(*user))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	after, err := patch.Apply(c.ContentType(), before, body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	changed, err := patch.Changed(before, after)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Users may keep their own email
+	c.Request = c.Request.WithContext(validation.ExcludingUser(c.Request.Context(), user.ID))
+	var patched dto.UserPatch
+	if !bindPatched(c, uc.validator, after, &patched) {
+		return
+	}
+
+	for _, field := range changed {
+		if !policy.CanPatchUserField(principal, user.ID, role, field) {
+			c.Error(custom_error.Forbidden("You cannot change " + field))
+			return
+		}
+	}
+	if patched.Role != user.Role && !uc.canAssignRole(c, patched.Role) {
+		c.Error(custom_error.Forbidden("You cannot assign the role " + string(patched.Role)))
+		return
+	}
+
+	if data := patched.Changes(changed); len(data) > 0 {
+		if err := uc.userService.UpdateUserDetails(c.Request.Context(), user, data); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "user": dto.NewUserResponse(*user)})
 }
 
-// PaginateUsers - Calls the PaginateUsers method in the service
-func (uc *UserController) PaginateUsers(c *gin.Context) {
-	var requestBody struct {
-		Page     int `json:"page"`
-		PageSize int `json:"pageSize"`
+// canUpdate checks the authenticated user may update or delete the given user
+func (uc *UserController) canUpdate(c *gin.Context, user *models.User) bool {
+	principal, ok := middlewares.CurrentPrincipal(c)
+	if !ok {
+		return false
+	}
+
+	// A user whose role is unknown can only update themselves
+	role, _ := uc.roleService.GetRole(user.Role)
+	return policy.CanUpdateUser(principal, user.ID, role)
+}
+
+// canAssignRole checks the role exists and grants nothing the authenticated user lacks
+func (uc *UserController) canAssignRole(c *gin.Context, name enum.Role) bool {
+	principal, ok := middlewares.CurrentPrincipal(c)
+	if !ok {
+		return false
 	}
 
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	role, err := uc.roleService.GetRole(name)
+	if err != nil {
+		return false
+	}
+	return policy.CanAssignRole(principal, role)
+}
+
+// DeleteUser - Calls the DeleteUser method in the service
+func (uc *UserController) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	user, err := uc.userService.GetUserById(c.Request.Context(), id, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !uc.canUpdate(c, user) {
+		c.Error(custom_error.Forbidden("You cannot delete this user"))
+		return
+	}
+	if !checkIfMatch(c, user.Version) {
 		return
 	}
 
-	if requestBody.Page <= 0 {
-		requestBody.Page = 1
+	if err := uc.userService.DeleteUser(c.Request.Context(), id, user.Version); err != nil {
+		c.Error(err)
+		return
 	}
 
-	if requestBody.PageSize <= 0 {
-		requestBody.PageSize = 10
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// PaginateUsers - Lists users a page at a time. The page size and cursor are
+// read from the limit, after and before query parameters, filters and order
+// from filter[...] and sort, and the returned fields from fields and include.
+func (uc *UserController) PaginateUsers(c *gin.Context) {
+	query, selection, params, ok := parseListQuery(c, repository.UserQuery, repository.UserFields)
+	if !ok {
+		return
 	}
 
-	users, err := uc.userService.PaginateUsers(requestBody.Page, requestBody.PageSize)
+	page, err := uc.userService.PaginateUsers(c.Request.Context(), query, selection, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": users})
+	respondPage(c, pagination.Map(page, dto.NewUserResponse), selection)
 }
 
 func (uc *UserController) LoginUser(c *gin.Context) {
@@ -138,19 +269,25 @@ func (uc *UserController) LoginUser(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&userLogin); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(custom_error.Wrap(custom_error.KindBadRequest, "Email and password are required", err))
 		return
 	}
 
 	// Authenticate the user using the service layer
-	token, err := uc.userService.AuthenticateUser(userLogin.Email, userLogin.Password)
+	user, err := uc.userService.AuthenticateUser(c.Request.Context(), userLogin.Email, userLogin.Password)
+	if err != nil {
+		c.Error(custom_error.Wrap(custom_error.KindUnauthorized, "Invalid email or password", err))
+		return
+	}
+
+	tokens, err := uc.tokenService.IssueTokens(user)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		c.Error(err)
 		return
 	}
 
-	// Respond with the token
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	// Respond with the access and refresh tokens
+	c.JSON(http.StatusOK, tokens)
 }
 
 // var googleOauthConfig = &oauth2.Config{
@@ -0,0 +1,99 @@
+package custom_error
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies a domain error; it decides the HTTP status it is reported with.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnsupportedMediaType
+	KindPreconditionFailed
+	KindPreconditionRequired
+)
+
+var statusByKind = map[Kind]int{
+	KindInternal:     http.StatusInternalServerError,
+	KindBadRequest:   http.StatusBadRequest,
+	KindUnauthorized: http.StatusUnauthorized,
+	KindForbidden:    http.StatusForbidden,
+	KindNotFound:     http.StatusNotFound,
+	KindConflict:     http.StatusConflict,
+	KindValidation:   http.StatusUnprocessableEntity,
+
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindPreconditionRequired: http.StatusPreconditionRequired,
+}
+
+// Status is the HTTP status code errors of this kind are reported with.
+func (k Kind) Status() int {
+	return statusByKind[k]
+}
+
+// Error is a domain error. Its message is safe to show to clients; the
+// wrapped cause, if any, is only logged.
+type Error struct {
+	Kind    Kind
+	Message string
+	// Details are reported alongside the message, e.g. the invalid fields of a payload
+	Details interface{}
+	Err     error
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap attaches a kind and client-facing message to a cause.
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func NotFound(message string) *Error     { return New(KindNotFound, message) }
+func Conflict(message string) *Error     { return New(KindConflict, message) }
+func Forbidden(message string) *Error    { return New(KindForbidden, message) }
+func Unauthorized(message string) *Error { return New(KindUnauthorized, message) }
+func BadRequest(message string) *Error   { return New(KindBadRequest, message) }
+
+// Validation reports an invalid payload, details list what is wrong with it.
+func Validation(message string, details interface{}) *Error {
+	return &Error{Kind: KindValidation, Message: message, Details: details}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same kind and message, so a sentinel still
+// matches after being copied with details attached.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Message == e.Message
+}
+
+// KindOf returns the kind of the first domain error in err's chain,
+// KindInternal if there is none.
+func KindOf(err error) Kind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return KindInternal
+}
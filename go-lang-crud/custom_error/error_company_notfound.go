@@ -0,0 +1,4 @@
+package custom_error
+
+// ErrCompanyNotFound represents an error when a company is not found.
+var ErrCompanyNotFound = NotFound("company not found")
@@ -0,0 +1,4 @@
+package custom_error
+
+// ErrIdentityNotFound represents an external account that isn't linked to any user.
+var ErrIdentityNotFound = NotFound("external identity not found")
@@ -0,0 +1,7 @@
+package custom_error
+
+// ErrPostNotFound represents an error when a post is not found.
+var ErrPostNotFound = NotFound("post not found")
+
+// ErrInvalidPostStatus represents a post status other than draft, published or archived.
+var ErrInvalidPostStatus = New(KindValidation, "invalid post status")
@@ -0,0 +1,8 @@
+package custom_error
+
+var (
+	// ErrPreconditionRequired represents an update without an If-Match header.
+	ErrPreconditionRequired = New(KindPreconditionRequired, "If-Match header is required")
+	// ErrPreconditionFailed represents an update to a version that is no longer current.
+	ErrPreconditionFailed = New(KindPreconditionFailed, "resource has been modified, fetch it again")
+)
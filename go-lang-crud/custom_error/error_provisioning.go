@@ -0,0 +1,4 @@
+package custom_error
+
+// ErrProvisioningDenied represents an external sign-in that cannot be mapped to a new user.
+var ErrProvisioningDenied = Forbidden("user provisioning denied")
@@ -0,0 +1,7 @@
+package custom_error
+
+// ErrInvalidRefreshToken represents a refresh token that is unknown, expired or revoked.
+var ErrInvalidRefreshToken = Unauthorized("invalid refresh token")
+
+// ErrRefreshTokenReused represents an already rotated refresh token being presented again.
+var ErrRefreshTokenReused = Unauthorized("refresh token reuse detected")
@@ -0,0 +1,10 @@
+package custom_error
+
+// ErrRoleNotFound represents an error when a role is not found.
+var ErrRoleNotFound = NotFound("role not found")
+
+// ErrRoleInUse represents an attempt to delete a role that users are still assigned.
+var ErrRoleInUse = Conflict("role is assigned to users")
+
+// ErrUnknownPermission represents a permission name the application doesn't check.
+var ErrUnknownPermission = New(KindValidation, "unknown permission")
@@ -0,0 +1,4 @@
+package custom_error
+
+// ErrParentDeleted represents restoring a record whose parent is still in the trash.
+var ErrParentDeleted = Conflict("parent record is deleted, restore it first")
@@ -1,6 +1,4 @@
 package custom_error
 
-import "errors"
-
 // ErrUserNotFound represents an error when a user is not found.
-var ErrUserNotFound = errors.New("user not found")
\ No newline at end of file
+var ErrUserNotFound = NotFound("user not found")
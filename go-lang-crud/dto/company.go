@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"golang-crud/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CompanyResponse struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Users are only present when loaded
+	Users []UserResponse `json:"users,omitempty"`
+}
+
+func NewCompanyResponse(company models.Company) CompanyResponse {
+	return CompanyResponse{
+		ID:        company.ID,
+		Name:      company.Name,
+		DeletedAt: deletedAt(company.DeletedAt),
+		Users:     NewUserResponses(company.Users),
+	}
+}
+
+func NewCompanyResponses(companies []models.Company) []CompanyResponse {
+	if companies == nil {
+		return nil
+	}
+	responses := make([]CompanyResponse, len(companies))
+	for i, company := range companies {
+		responses[i] = NewCompanyResponse(company)
+	}
+	return responses
+}
+
+type CreateCompanyRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+func (r CreateCompanyRequest) Model() *models.Company {
+	return &models.Company{Name: r.Name}
+}
+
+// UpdateCompanyRequest is used for both PUT and PATCH; PATCH leaves absent fields unchanged
+type UpdateCompanyRequest struct {
+	Name *string `json:"name" validate:"omitempty,min=1,max=100"`
+}
+
+// deletedAt is set only on records in the trash
+func deletedAt(deleted gorm.DeletedAt) *time.Time {
+	if !deleted.Valid {
+		return nil
+	}
+	return &deleted.Time
+}
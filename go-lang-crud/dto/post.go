@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"golang-crud/enum"
+	"golang-crud/models"
+	"time"
+)
+
+type PostResponse struct {
+	ID          uint            `json:"id"`
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`
+	UserID      uint            `json:"user_id"`
+	Status      enum.PostStatus `json:"status"`
+	PublishedAt *time.Time      `json:"published_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	DeletedAt   *time.Time      `json:"deleted_at,omitempty"`
+	// User is the author, only present when loaded
+	User *UserResponse `json:"user,omitempty"`
+}
+
+func NewPostResponse(post models.Post) PostResponse {
+	response := PostResponse{
+		ID:          post.ID,
+		Title:       post.Title,
+		Body:        post.Body,
+		UserID:      post.UserId,
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		DeletedAt:   deletedAt(post.DeletedAt),
+	}
+	if post.User != nil {
+		user := NewUserResponse(*post.User)
+		response.User = &user
+	}
+	return response
+}
+
+func NewPostResponses(posts []models.Post) []PostResponse {
+	if posts == nil {
+		return nil
+	}
+	responses := make([]PostResponse, len(posts))
+	for i, post := range posts {
+		responses[i] = NewPostResponse(post)
+	}
+	return responses
+}
+
+type CreatePostRequest struct {
+	Title  string `json:"title" validate:"required,max=255"`
+	Body   string `json:"body" validate:"required"`
+	UserID uint   `json:"user_id"`
+	// Status defaults to draft
+	Status      enum.PostStatus `json:"status" validate:"omitempty,post_status"`
+	PublishedAt *time.Time      `json:"published_at"`
+}
+
+func (r CreatePostRequest) Model() *models.Post {
+	return &models.Post{
+		Title:       r.Title,
+		Body:        r.Body,
+		UserId:      r.UserID,
+		Status:      r.Status,
+		PublishedAt: r.PublishedAt,
+	}
+}
+
+// UpdatePostRequest only changes the fields present in the body
+type UpdatePostRequest struct {
+	Title       *string          `json:"title" validate:"omitempty,min=1,max=255"`
+	Body        *string          `json:"body" validate:"omitempty,min=1"`
+	Status      *enum.PostStatus `json:"status" validate:"omitempty,post_status"`
+	PublishedAt *time.Time       `json:"published_at"`
+}
+
+// Changes lists the columns to update
+func (r UpdatePostRequest) Changes() map[string]interface{} {
+	data := map[string]interface{}{}
+	if r.Title != nil {
+		data["title"] = *r.Title
+	}
+	if r.Body != nil {
+		data["body"] = *r.Body
+	}
+	if r.Status != nil {
+		data["status"] = *r.Status
+	}
+	if r.PublishedAt != nil {
+		data["published_at"] = *r.PublishedAt
+	}
+	return data
+}
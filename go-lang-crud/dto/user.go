@@ -0,0 +1,116 @@
+// Package dto holds the API representations of the models. Responses are
+// built from models and never carry secrets such as password hashes; requests
+// only bind the fields a client may set.
+package dto
+
+import (
+	"golang-crud/enum"
+	"golang-crud/models"
+	"time"
+)
+
+type UserResponse struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Role      enum.Role  `json:"role"`
+	CompanyID *uint      `json:"company_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Associations, only present when loaded
+	Company *CompanyResponse `json:"company,omitempty"`
+	Posts   []PostResponse   `json:"posts,omitempty"`
+}
+
+func NewUserResponse(user models.User) UserResponse {
+	response := UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		CompanyID: user.CompanyID,
+		CreatedAt: user.CreatedAt,
+		DeletedAt: deletedAt(user.DeletedAt),
+		Posts:     NewPostResponses(user.Posts),
+	}
+	if user.Company.ID != 0 {
+		company := NewCompanyResponse(user.Company)
+		response.Company = &company
+	}
+	return response
+}
+
+func NewUserResponses(users []models.User) []UserResponse {
+	if users == nil {
+		return nil
+	}
+	responses := make([]UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = NewUserResponse(user)
+	}
+	return responses
+}
+
+type CreateUserRequest struct {
+	Name      string    `json:"name" validate:"required,max=100"`
+	Email     string    `json:"email" validate:"required,email,max=100,unique_email"`
+	Password  string    `json:"password" validate:"required,password"`
+	Role      enum.Role `json:"role"`
+	CompanyID *uint     `json:"company_id"`
+}
+
+func (r CreateUserRequest) Model() *models.User {
+	return &models.User{
+		Name:      r.Name,
+		Email:     r.Email,
+		Password:  r.Password,
+		Role:      r.Role,
+		CompanyID: r.CompanyID,
+	}
+}
+
+type UpdateUserRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Email string `json:"email" validate:"required,email,max=100,unique_email"`
+}
+
+// UserPatch is the document PATCH /user/:id applies patches to. The
+// password is write-only: it is absent until a patch adds it.
+type UserPatch struct {
+	Name      string    `json:"name" validate:"required,max=100"`
+	Email     string    `json:"email" validate:"required,email,max=100,unique_email"`
+	Password  *string   `json:"password,omitempty" validate:"omitnil,password"`
+	Role      enum.Role `json:"role" validate:"required"`
+	CompanyID *uint     `json:"company_id"`
+}
+
+func NewUserPatch(user models.User) UserPatch {
+	return UserPatch{
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		CompanyID: user.CompanyID,
+	}
+}
+
+// Changes returns the columns to update for the changed fields.
+func (p UserPatch) Changes(fields []string) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			data["name"] = p.Name
+		case "email":
+			data["email"] = p.Email
+		case "password":
+			if p.Password != nil {
+				data["password"] = *p.Password
+			}
+		case "role":
+			data["role"] = p.Role
+		case "company_id":
+			data["company_id"] = p.CompanyID
+		}
+	}
+	return data
+}
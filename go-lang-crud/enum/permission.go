@@ -0,0 +1,67 @@
+package enum
+
+// Permission names a single action on a resource, as "resource:action"
+type Permission string
+
+const (
+	UsersList   Permission = "users:list"
+	UsersRead   Permission = "users:read"
+	UsersCreate Permission = "users:create"
+	UsersWrite  Permission = "users:write"
+	UsersDelete Permission = "users:delete"
+	// UsersManage lets the holder act on other users' profiles, not only their own
+	UsersManage Permission = "users:manage"
+
+	PostsRead   Permission = "posts:read"
+	PostsWrite  Permission = "posts:write"
+	PostsDelete Permission = "posts:delete"
+	// PostsManage lets the holder act on other users' posts, not only their own
+	PostsManage Permission = "posts:manage"
+
+	CompaniesRead   Permission = "companies:read"
+	CompaniesWrite  Permission = "companies:write"
+	CompaniesDelete Permission = "companies:delete"
+
+	RolesRead  Permission = "roles:read"
+	RolesWrite Permission = "roles:write"
+
+	// TrashManage lets the holder list and restore deleted records
+	TrashManage Permission = "trash:manage"
+
+	// TenantsAll lifts company scoping, making the holder a super admin
+	TenantsAll Permission = "tenants:all"
+	// Add new permissions here and to AllPermissions
+)
+
+// AllPermissions lists every permission the application checks
+var AllPermissions = []Permission{
+	UsersList, UsersRead, UsersCreate, UsersWrite, UsersDelete, UsersManage,
+	PostsRead, PostsWrite, PostsDelete, PostsManage,
+	CompaniesRead, CompaniesWrite, CompaniesDelete,
+	RolesRead, RolesWrite,
+	TrashManage,
+	TenantsAll,
+}
+
+// DefaultRolePermissions are the permission sets the built-in roles are
+// seeded with. Once seeded, roles are managed in the database.
+var DefaultRolePermissions = map[Role][]Permission{
+	Admin: AllPermissions,
+	TenantAdmin: {
+		UsersList, UsersRead, UsersCreate, UsersWrite, UsersDelete, UsersManage,
+		PostsRead, PostsWrite, PostsDelete, PostsManage,
+		CompaniesRead, RolesRead, TrashManage,
+	},
+	User:  {UsersRead, UsersWrite, PostsRead, PostsWrite, PostsDelete, CompaniesRead},
+	Guest: {PostsRead},
+}
+
+// IsValid reports whether the permission is one the application knows
+func (p Permission) IsValid() bool {
+	for _, permission := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package enum
+
+// PostStatus is the publishing state of a post
+type PostStatus string
+
+const (
+	Draft     PostStatus = "draft"
+	Published PostStatus = "published"
+	Archived  PostStatus = "archived"
+)
+
+// IsValid reports whether the status is one of the known states
+func (s PostStatus) IsValid() bool {
+	return s == Draft || s == Published || s == Archived
+}
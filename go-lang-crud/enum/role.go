@@ -7,5 +7,7 @@ const (
 	Admin Role = "admin"
 	User  Role = "user"
 	Guest Role = "guest"
+	// TenantAdmin administers the users and posts of their own company
+	TenantAdmin Role = "tenant_admin"
 	// Add new roles here in the future
 )
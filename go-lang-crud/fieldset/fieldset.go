@@ -0,0 +1,203 @@
+// Package fieldset implements sparse fieldsets and association includes:
+// ?fields=id,name limits the columns loaded and returned, ?include=posts
+// opts into loading an association. Both are validated against a per-resource
+// whitelist.
+package fieldset
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"net/url"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidFieldset is returned for fields or includes a resource does not expose.
+var ErrInvalidFieldset = custom_error.BadRequest("invalid fieldset")
+
+// Field maps a fields parameter name to its column and its JSON key in the response DTO.
+type Field struct {
+	Column string
+	Key    string
+}
+
+// Include is an association that can be loaded on request.
+type Include struct {
+	// Association is the name of the association field on the model
+	Association string
+	// Key is the association's key in the response
+	Key string
+	// ForeignKey is a column of this resource the association is loaded by, if any
+	ForeignKey string
+	// Conditions optionally limit the associated records that are loaded
+	Conditions func(*gorm.DB) *gorm.DB
+	// Permission is required to include the association, the one its own
+	// endpoint requires, so includes can't reveal more than that endpoint
+	Permission enum.Permission
+}
+
+// Resource is the whitelist of fields and includes of a resource.
+type Resource struct {
+	Fields   map[string]Field
+	Includes map[string]Include
+	// Required columns are always loaded, e.g. the primary key and columns
+	// authorization checks depend on, but only returned when asked for
+	Required []string
+}
+
+// Selection is a parsed, validated fieldset and list of includes.
+type Selection struct {
+	resource Resource
+	fields   []string
+	includes []string
+}
+
+// Parse reads the fields and include parameters, checking them against the resource.
+func (r Resource) Parse(values url.Values) (*Selection, error) {
+	selection := &Selection{resource: r}
+
+	for _, name := range split(values.Get("fields")) {
+		if _, ok := r.Fields[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFieldset, name)
+		}
+		if !slices.Contains(selection.fields, name) {
+			selection.fields = append(selection.fields, name)
+		}
+	}
+
+	for _, name := range split(values.Get("include")) {
+		if _, ok := r.Includes[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown include %q", ErrInvalidFieldset, name)
+		}
+		if !slices.Contains(selection.includes, name) {
+			selection.includes = append(selection.includes, name)
+		}
+	}
+
+	return selection, nil
+}
+
+func split(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Key identifies the representation the selection renders: the requested
+// fields and includes, sorted, or "" for the full record.
+func (s *Selection) Key() string {
+	if s == nil {
+		return ""
+	}
+	fields, includes := slices.Sorted(slices.Values(s.fields)), slices.Sorted(slices.Values(s.includes))
+	if len(fields) == 0 && len(includes) == 0 {
+		return ""
+	}
+	return "fields=" + strings.Join(fields, ",") + "&include=" + strings.Join(includes, ",")
+}
+
+// Permissions lists the permissions the requested includes require.
+func (s *Selection) Permissions() []enum.Permission {
+	if s == nil {
+		return nil
+	}
+	var permissions []enum.Permission
+	for _, name := range s.includes {
+		if permission := s.resource.Includes[name].Permission; permission != "" && !slices.Contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// HasIncludes reports whether any association was requested.
+func (s *Selection) HasIncludes() bool {
+	return s != nil && len(s.includes) > 0
+}
+
+// Scope selects the requested columns, along with the required columns and
+// any extra ones the caller needs, and preloads the requested includes. A nil
+// selection loads every column and no associations.
+func (s *Selection) Scope(extra ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if s == nil {
+			return db
+		}
+
+		if len(s.fields) > 0 {
+			columns := slices.Clone(s.resource.Required)
+			add := func(column string) {
+				if column != "" && !slices.Contains(columns, column) {
+					columns = append(columns, column)
+				}
+			}
+			for _, name := range s.fields {
+				add(s.resource.Fields[name].Column)
+			}
+			for _, name := range s.includes {
+				add(s.resource.Includes[name].ForeignKey)
+			}
+			for _, column := range extra {
+				add(column)
+			}
+			db = db.Select(columns)
+		}
+
+		for _, name := range s.includes {
+			include := s.resource.Includes[name]
+			if include.Conditions != nil {
+				db = db.Preload(include.Association, include.Conditions)
+			} else {
+				db = db.Preload(include.Association)
+			}
+		}
+		return db
+	}
+}
+
+// Project renders a record with only the requested fields, or every field
+// when none were requested, and only the requested includes.
+func (s *Selection) Project(record interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var projected map[string]json.RawMessage
+	if err := json.Unmarshal(data, &projected); err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return projected, nil
+	}
+
+	keep := map[string]bool{}
+	for _, name := range s.includes {
+		keep[s.resource.Includes[name].Key] = true
+	}
+	if len(s.fields) > 0 {
+		for _, name := range s.fields {
+			keep[s.resource.Fields[name].Key] = true
+		}
+		for key := range projected {
+			if !keep[key] {
+				delete(projected, key)
+			}
+		}
+		return projected, nil
+	}
+
+	for _, include := range s.resource.Includes {
+		if !keep[include.Key] {
+			delete(projected, include.Key)
+		}
+	}
+	return projected, nil
+}
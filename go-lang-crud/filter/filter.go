@@ -0,0 +1,220 @@
+// Package filter parses the filter and sort query parameters of list
+// endpoints, e.g. ?filter[role]=admin&filter[created_at][gte]=2024-01-01&sort=-created_at,name,
+// and applies them to GORM queries. Every resource declares which of its
+// fields may be filtered and sorted on; anything else is rejected.
+package filter
+
+import (
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/pagination"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidQuery is returned for filters or sorts a resource does not allow.
+var ErrInvalidQuery = custom_error.BadRequest("invalid query")
+
+type Operator string
+
+const (
+	Eq   Operator = "eq"
+	Ne   Operator = "ne"
+	Gt   Operator = "gt"
+	Gte  Operator = "gte"
+	Lt   Operator = "lt"
+	Lte  Operator = "lte"
+	In   Operator = "in"
+	Like Operator = "like"
+)
+
+// Type is the type a filter value is parsed as before it reaches the database.
+type Type int
+
+const (
+	String Type = iota
+	Number
+	Time
+)
+
+var operatorsByType = map[Type][]Operator{
+	String: {Eq, Ne, In, Like},
+	Number: {Eq, Ne, Gt, Gte, Lt, Lte, In},
+	Time:   {Eq, Gt, Gte, Lt, Lte},
+}
+
+// Field is a query parameter name a resource exposes for filtering or sorting.
+type Field struct {
+	Column string
+	Type   Type
+	// Sortable fields must be NOT NULL, keyset pagination can't seek past NULLs
+	Sortable bool
+}
+
+// Resource is the whitelist of fields a list endpoint can be filtered and sorted by.
+type Resource struct {
+	Fields      map[string]Field
+	DefaultSort []pagination.Column
+}
+
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    interface{}
+}
+
+// Query is a parsed, validated set of filters and sort order.
+type Query struct {
+	Conditions []Condition
+	Sort       []pagination.Column
+}
+
+// Parse reads the filter[...] and sort parameters, checking them against the resource.
+func (r Resource) Parse(values url.Values) (*Query, error) {
+	query := &Query{Sort: r.DefaultSort}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, operator, err := parseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range values[key] {
+			condition, err := r.condition(name, operator, param)
+			if err != nil {
+				return nil, err
+			}
+			query.Conditions = append(query.Conditions, condition)
+		}
+	}
+
+	if order := values.Get("sort"); order != "" {
+		query.Sort = nil
+		for _, name := range strings.Split(order, ",") {
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+
+			field, ok := r.Fields[name]
+			if !ok || !field.Sortable {
+				return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+			}
+			query.Sort = append(query.Sort, pagination.Column{Name: field.Column, Desc: desc})
+		}
+	}
+
+	return query, nil
+}
+
+// parseKey splits filter[name] and filter[name][operator]; the operator defaults to eq.
+func parseKey(key string) (string, Operator, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]"), "][")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return parts[0], Eq, nil
+	case len(parts) == 2 && parts[0] != "":
+		return parts[0], Operator(parts[1]), nil
+	}
+	return "", "", fmt.Errorf("%w: malformed filter %q", ErrInvalidQuery, key)
+}
+
+func (r Resource) condition(name string, operator Operator, param string) (Condition, error) {
+	field, ok := r.Fields[name]
+	if !ok {
+		return Condition{}, fmt.Errorf("%w: cannot filter by %q", ErrInvalidQuery, name)
+	}
+
+	allowed := false
+	for _, candidate := range operatorsByType[field.Type] {
+		allowed = allowed || candidate == operator
+	}
+	if !allowed {
+		return Condition{}, fmt.Errorf("%w: operator %q is not supported on %q", ErrInvalidQuery, operator, name)
+	}
+
+	if operator == In {
+		var values []interface{}
+		for _, raw := range strings.Split(param, ",") {
+			value, err := parseValue(field.Type, raw)
+			if err != nil {
+				return Condition{}, fmt.Errorf("%w: filter %q: %v", ErrInvalidQuery, name, err)
+			}
+			values = append(values, value)
+		}
+		return Condition{Column: field.Column, Operator: In, Value: values}, nil
+	}
+
+	value, err := parseValue(field.Type, param)
+	if err != nil {
+		return Condition{}, fmt.Errorf("%w: filter %q: %v", ErrInvalidQuery, name, err)
+	}
+	return Condition{Column: field.Column, Operator: operator, Value: value}, nil
+}
+
+func parseValue(fieldType Type, raw string) (interface{}, error) {
+	switch fieldType {
+	case Number:
+		if integer, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return integer, nil
+		}
+		return strconv.ParseFloat(raw, 64)
+	case Time:
+		for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+			if value, err := time.Parse(layout, raw); err == nil {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not an RFC 3339 timestamp or date", raw)
+	}
+	return raw, nil
+}
+
+// Scope adds the filter conditions to a query. Sorting is left to pagination.
+func (q *Query) Scope(db *gorm.DB) *gorm.DB {
+	if q == nil {
+		return db
+	}
+	for _, condition := range q.Conditions {
+		db = db.Where(condition.expression())
+	}
+	return db
+}
+
+func (c Condition) expression() clause.Expression {
+	column := clause.Column{Name: c.Column}
+	switch c.Operator {
+	case Ne:
+		return clause.Neq{Column: column, Value: c.Value}
+	case Gt:
+		return clause.Gt{Column: column, Value: c.Value}
+	case Gte:
+		return clause.Gte{Column: column, Value: c.Value}
+	case Lt:
+		return clause.Lt{Column: column, Value: c.Value}
+	case Lte:
+		return clause.Lte{Column: column, Value: c.Value}
+	case In:
+		return clause.IN{Column: column, Values: c.Value.([]interface{})}
+	case Like:
+		return clause.Expr{SQL: "? ILIKE ?", Vars: []interface{}{column, "%" + escapeLike(c.Value.(string)) + "%"}}
+	}
+	return clause.Eq{Column: column, Value: c.Value}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
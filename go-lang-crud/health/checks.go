@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"golang-crud/migrations"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Database checks that a connection to the database can be used.
+func Database(db *gorm.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// Migrations checks that every migration the application knows about has
+// been applied, so it isn't served against an older schema.
+func Migrations(migrator *migrations.Migrator) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			names := make([]string, len(pending))
+			for i, migration := range pending {
+				names[i] = migration.String()
+			}
+			return fmt.Errorf("%d pending migrations: %s", len(pending), strings.Join(names, ", "))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+// Package health reports whether the application and the components it
+// depends on are working, for liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	Up   Status = "up"
+	Down Status = "down"
+)
+
+// Checker reports whether a component works, returning why it doesn't.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Component is the outcome of one check. Its error is left out of the JSON
+// since probes are unauthenticated and errors can name hosts or users.
+type Component struct {
+	Status    Status  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Err       error   `json:"-"`
+}
+
+// Report is the outcome of every check, up only if they all are.
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Health runs the registered checks, each within the timeout.
+type Health struct {
+	timeout  time.Duration
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout, checkers: map[string]Checker{}}
+}
+
+// Register adds the checker under name, replacing any with the same name.
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers[name] = checker
+}
+
+// Check runs every checker concurrently. A checker still running when the
+// timeout expires is reported down.
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := Report{Status: Up, Components: make(map[string]Component, len(h.checkers))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, checker := range h.checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			component := run(ctx, checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if component.Status == Down {
+				report.Status = Down
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+	return report
+}
+
+func run(ctx context.Context, checker Checker) Component {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- checker.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	component := Component{
+		Status:    Up,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Err:       err,
+	}
+	if err != nil {
+		component.Status = Down
+	}
+	return component
+}
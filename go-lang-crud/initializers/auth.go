@@ -1,23 +1,35 @@
 package initializers
 
 import (
+	"fmt"
+	"golang-crud/config"
+	"golang-crud/oauth"
 	"log"
-	"os"
-
-	"github.com/markbates/goth"
-	"github.com/markbates/goth/providers/google"
 )
 
-func ConnectToGoogle() {
-	clientID := os.Getenv("CLIENT_ID")
-	clientSecret := os.Getenv("CLIENT_SECRET")
-	clientCallbackURL := os.Getenv("Callback_URL")
-
-	if clientID == "" || clientSecret == "" || clientCallbackURL == "" {
-		log.Fatal("Environment variables (CLIENT_ID, CLIENT_SECRET, CLIENT_CALLBACK_URL) are required")
+// ConnectToProviders builds the registry of the configured identity providers.
+func ConnectToProviders(cfg config.Auth) (*oauth.Registry, error) {
+	registry := oauth.NewRegistry(cfg.SessionSecret.Value())
+	if len(cfg.Providers) == 0 {
+		log.Println("No identity providers configured, social login is disabled")
+		return registry, nil
 	}
 
-	goth.UseProviders(
-		google.New(clientID, clientSecret, clientCallbackURL, "email", "profile"),
-	)
+	for _, settings := range cfg.Providers {
+		provider, err := oauth.NewProvider(oauth.ProviderConfig{
+			Name:         settings.Name,
+			Type:         settings.Type,
+			ClientID:     settings.ClientID,
+			ClientSecret: settings.ClientSecret.Value(),
+			CallbackURL:  settings.CallbackURL,
+			DiscoveryURL: settings.DiscoveryURL,
+			Scopes:       settings.Scopes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("configure identity provider: %w", err)
+		}
+		registry.Add(provider)
+		log.Println("Enabled identity provider ", settings.Name)
+	}
+	return registry, nil
 }
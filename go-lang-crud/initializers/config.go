@@ -0,0 +1,17 @@
+package initializers
+
+import (
+	"golang-crud/config"
+	"log"
+)
+
+// LoadConfig reads the settings of the APP_ENV profile, stopping with every
+// problem found if they are invalid.
+func LoadConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Loaded %s configuration:\n%s", cfg.Env, cfg)
+	return cfg
+}
@@ -1,7 +1,12 @@
 package initializers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"golang-crud/config"
+	"golang-crud/enum"
+	"golang-crud/migrations"
 	"golang-crud/models"
 	"log"
 	"os"
@@ -12,23 +17,80 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+// MigrateDB applies the pending schema migrations, unless auto-migration is
+// turned off, and seeds the built-in roles. Deployments that migrate
+// separately run "go run ./cmd/migrate up" first.
+func MigrateDB(db *gorm.DB, cfg config.Database) error {
+	if cfg.AutoMigrate {
+		migrationList, err := migrations.Load(migrations.Embedded())
+		if err != nil {
+			return fmt.Errorf("load migrations: %w", err)
+		}
+		applied, err := migrations.New(db, migrationList).Up(context.Background())
+		if err != nil {
+			return fmt.Errorf("migrate database: %w", err)
+		}
+		for _, migration := range applied {
+			log.Println("Applied migration ", migration)
+		}
+	}
 
-func migrateToDb() {
-	// Migrate the schema, including relationships
-	migrationError := DB.AutoMigrate(&models.User{}, &models.Post{}, &models.Company{})
-	if migrationError != nil {
-		fmt.Println("Error in autoMigrate : ", migrationError)
+	if err := seedRoles(db); err != nil {
+		return fmt.Errorf("seed roles: %w", err)
 	}
+	return nil
+}
+
+// seedRoles creates the permissions the application checks and the built-in
+// roles. Existing roles are left alone so changes made through the API stick,
+// except admin, which always holds every permission. It fails on a database
+// that hasn't been migrated, where every permission check would be denied.
+func seedRoles(db *gorm.DB) error {
+	for _, name := range enum.AllPermissions {
+		if err := db.FirstOrCreate(&models.Permission{}, models.Permission{Name: name}).Error; err != nil {
+			return fmt.Errorf("permission %s: %w", name, err)
+		}
+	}
+
+	for name, permissionNames := range enum.DefaultRolePermissions {
+		var permissions []models.Permission
+		if err := db.Where("name IN ?", permissionNames).Find(&permissions).Error; err != nil {
+			return fmt.Errorf("permissions of role %s: %w", name, err)
+		}
+
+		var role models.Role
+		err := db.Where("name = ?", name).First(&role).Error
+		if err == nil {
+			if name == enum.Admin {
+				if err := db.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+					return fmt.Errorf("role %s: %w", name, err)
+				}
+			}
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("role %s: %w", name, err)
+		}
+
+		role = models.Role{Name: name, Description: "Built-in " + string(name) + " role", Permissions: permissions}
+		if err := db.Create(&role).Error; err != nil {
+			return fmt.Errorf("role %s: %w", name, err)
+		}
+	}
+	return nil
 }
 
 // To print database queries logs on terminal
-func getLogger() logger.Interface {
+func getLogger(logQueries bool) logger.Interface {
+	level := logger.Warn
+	if logQueries {
+		level = logger.Info
+	}
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
 		logger.Config{
 			SlowThreshold:             time.Second, // Log SQL queries slower than this threshold
-			LogLevel:                  logger.Info, // Log level (Info, Warn, Error)
+			LogLevel:                  level,       // Log level (Info, Warn, Error)
 			IgnoreRecordNotFoundError: true,        // Ignore record not found errors
 			Colorful:                  true,        // Enable color logging
 		},
@@ -36,22 +98,20 @@ func getLogger() logger.Interface {
 	return newLogger
 }
 
-func ConnectToDB() {
-	var err error
-	dsn := os.Getenv("DB_URL")
-
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: getLogger(), // Assign the logger
+// ConnectToDB opens the database, checking that it can be reached.
+func ConnectToDB(cfg config.Database) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.URL.Value()), &gorm.Config{
+		Logger: getLogger(cfg.LogQueries), // Assign the logger
 	})
-
 	if err != nil {
-		log.Println("failed to connect database!")
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	migrateToDb()
-
 	// Retrieve and print the current database name
 	var dbName string
-	DB.Raw("SELECT current_database()").Scan(&dbName)
+	if err := db.Raw("SELECT current_database()").Scan(&dbName).Error; err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
 	fmt.Println("Connected to postgres db:", dbName)
+	return db, nil
 }
@@ -0,0 +1,34 @@
+package initializers
+
+import (
+	"fmt"
+	"golang-crud/config"
+	"golang-crud/security"
+	"log"
+)
+
+// LoadSigningKeys sets up the keys used to sign and verify JWTs. Keys are
+// read from the keys directory; without it an ephemeral key is generated,
+// which invalidates every token on restart and is only suitable for
+// development.
+func LoadSigningKeys(cfg config.JWT) (*security.KeyManager, error) {
+	if cfg.KeysDir != "" {
+		manager, err := security.LoadKeyManager(cfg.KeysDir, cfg.ActiveKID)
+		if err != nil {
+			return nil, fmt.Errorf("load jwt signing keys: %w", err)
+		}
+		return manager, nil
+	}
+
+	method, err := security.SigningMethodByName(cfg.SigningAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := security.NewKeyManager()
+	if _, err := manager.Rotate(method); err != nil {
+		return nil, fmt.Errorf("generate jwt signing key: %w", err)
+	}
+	log.Println("JWT_KEYS_DIR is not set, using an ephemeral signing key")
+	return manager, nil
+}
@@ -0,0 +1,24 @@
+package initializers
+
+import (
+	"golang-crud/config"
+	"golang-crud/enum"
+	"golang-crud/service"
+	"strings"
+)
+
+// LoadProvisioningOptions converts the just-in-time provisioning settings,
+// matching email domains case-insensitively.
+func LoadProvisioningOptions(cfg config.Provisioning) service.ProvisioningOptions {
+	domainCompanies := map[string]uint{}
+	for domain, companyID := range cfg.DomainCompanies {
+		domainCompanies[strings.ToLower(domain)] = companyID
+	}
+	return service.ProvisioningOptions{
+		Enabled:          cfg.Enabled,
+		DefaultRole:      enum.Role(cfg.DefaultRole),
+		DomainCompanies:  domainCompanies,
+		DefaultCompanyID: cfg.DefaultCompanyID,
+		TrustedProviders: cfg.TrustedProviders,
+	}
+}
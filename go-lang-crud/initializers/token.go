@@ -0,0 +1,15 @@
+package initializers
+
+import (
+	"golang-crud/config"
+	"golang-crud/security"
+)
+
+// LoadTokenOptions converts the JWT issuer, audience and clock-skew leeway.
+func LoadTokenOptions(cfg config.JWT) security.TokenOptions {
+	return security.TokenOptions{
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   cfg.Leeway,
+	}
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"golang-crud/service"
+	"golang-crud/tenant"
+	"log"
+	"time"
+)
+
+// StartTrashPurger permanently deletes records that have been in the trash
+// longer than retention, checking every interval until ctx is cancelled. The
+// returned channel is closed once it has stopped.
+func StartTrashPurger(ctx context.Context, trashService *service.TrashService, retention, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := trashService.Purge(tenant.System(ctx), retention)
+			if err != nil {
+				log.Println("Error purging trash : ", err)
+			} else if purged > 0 {
+				log.Println("Purged records from trash : ", purged)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
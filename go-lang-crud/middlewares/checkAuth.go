@@ -1,94 +1,130 @@
 package middlewares
 
 import (
-	"fmt"
+	"golang-crud/custom_error"
 	"golang-crud/enum"
-	"golang-crud/initializers"
 	"golang-crud/models"
-	"net/http"
-	"os"
+	"golang-crud/repository"
+	"golang-crud/security"
+	"golang-crud/tenant"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-// RoleAuthorization middleware to handle multiple roles
-func RoleAuthorization(requiredRoles ...enum.Role) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
+// Auth builds the middlewares authenticating requests with an access token
+// and authorizing them by role or permission.
+type Auth struct {
+	accessTokens  *security.AccessTokens
+	users         repository.UserRepository
+	roles         *repository.RoleRepository
+	refreshTokens *repository.RefreshTokenRepository
+}
 
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+func NewAuth(accessTokens *security.AccessTokens, users repository.UserRepository, roles *repository.RoleRepository, refreshTokens *repository.RefreshTokenRepository) *Auth {
+	return &Auth{accessTokens: accessTokens, users: users, roles: roles, refreshTokens: refreshTokens}
+}
 
-		authToken := strings.Split(authHeader, " ")
-		if len(authToken) != 2 || authToken[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+// authenticate verifies the bearer token and loads the user it was issued
+// to. It aborts the request with a 401 and returns false on failure.
+func (a *Auth) authenticate(c *gin.Context) (*models.User, bool) {
+	authHeader := c.GetHeader("Authorization")
 
-		tokenString := authToken[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(os.Getenv("SECRET")), nil
-		})
+	if authHeader == "" {
+		abort(c, custom_error.Unauthorized("Authorization header is missing"))
+		return nil, false
+	}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+	authToken := strings.Split(authHeader, " ")
+	if len(authToken) != 2 || authToken[0] != "Bearer" {
+		abort(c, custom_error.Unauthorized("Invalid token format"))
+		return nil, false
+	}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+	tokenString := authToken[1]
+	claims, err := a.accessTokens.Parse(tokenString)
+	if err != nil {
+		abort(c, custom_error.Unauthorized("Invalid or expired token"))
+		return nil, false
+	}
 
-		// Check token expiration
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+	// Reject tokens whose session was logged out or revoked
+	active, err := a.refreshTokens.IsFamilyActive(claims.SessionID)
+	if err != nil || !active {
+		abort(c, custom_error.Unauthorized("Session has been revoked"))
+		return nil, false
+	}
+
+	// Fetch the user from DB based on token's `sub` claim, before their
+	// tenant is known
+	user, err := a.users.FindById(tenant.System(c.Request.Context()), claims.Subject, nil)
+	if err != nil {
+		abort(c, custom_error.Unauthorized("User not found"))
+		return nil, false
+	}
 
-		// Fetch the user from DB based on token's `sub` claim
-		var user models.User
-		initializers.DB.Where("ID=?", claims["sub"]).Find(&user)
+	return user, true
+}
 
-		if user.ID == 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-			c.AbortWithStatus(http.StatusUnauthorized)
+// abort stops the chain, leaving err for ErrorHandler to report
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// RequirePermission middleware allows the request only if the user's role
+// grants every one of the given permissions
+func (a *Auth) RequirePermission(requiredPermissions ...enum.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := a.authenticate(c)
+		if !ok {
 			return
 		}
 
-		// Check if the user has one of the required roles
-		hasRole := false
-		for _, role := range requiredRoles {
-			if string(user.Role) == string(role) {
-				hasRole = true
-				break
-			}
+		role, err := a.roles.FindByName(user.Role)
+		if err != nil {
+			abort(c, custom_error.Forbidden("You do not have the required permission"))
+			return
 		}
 
-		if !hasRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have the required role"})
-			c.AbortWithStatus(http.StatusForbidden)
-			return
+		for _, permission := range requiredPermissions {
+			if !role.HasPermission(permission) {
+				abort(c, custom_error.Forbidden("You do not have the required permission: "+string(permission)))
+				return
+			}
 		}
 
-		// Set currentUser in context
-		c.Set("currentUser", user)
+		// Set currentUser and currentRole in context and scope the request to their company
+		c.Set("currentUser", *user)
+		c.Set("currentRole", role)
+		withTenant(c, user, role.HasPermission(enum.TenantsAll))
 
 		// Continue to the next handler
 		c.Next()
 	}
 }
+
+// AllowAnonymous lets requests without an Authorization header through as
+// anonymous readers, scoped with tenant.Public. Requests with one have to pass
+// RequirePermission with the given permissions.
+func (a *Auth) AllowAnonymous(requiredPermissions ...enum.Permission) gin.HandlerFunc {
+	requirePermission := a.RequirePermission(requiredPermissions...)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			requirePermission(c)
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.Public(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// withTenant limits every repository call made for the request to the
+// user's company, unless they are allowed to see all tenants.
+func withTenant(c *gin.Context, user *models.User, allTenants bool) {
+	scope := tenant.Scope{AllTenants: allTenants}
+	if user.CompanyID != nil {
+		scope.CompanyID = *user.CompanyID
+	}
+	c.Request = c.Request.WithContext(tenant.WithScope(c.Request.Context(), scope))
+}
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"errors"
+	"golang-crud/custom_error"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Errors    interface{} `json:"errors,omitempty"`
+}
+
+// ErrorHandler renders the last error a handler attached with c.Error as
+// application/problem+json. Domain errors are reported with their kind's
+// status and message; anything else is logged and reported as a 500
+// without details.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		problem := Problem{
+			Type:      "about:blank",
+			Instance:  c.Request.URL.Path,
+			RequestID: CurrentRequestID(c),
+		}
+
+		var domainErr *custom_error.Error
+		if errors.As(err, &domainErr) && domainErr.Kind != custom_error.KindInternal {
+			problem.Status = domainErr.Kind.Status()
+			problem.Detail = domainErr.Message
+			if domainErr.Err == nil {
+				// Without an internal cause the whole chain is client-safe
+				// context around the message, e.g. "invalid query: cannot sort by ..."
+				problem.Detail = err.Error()
+			}
+			problem.Errors = domainErr.Details
+		} else {
+			problem.Status = http.StatusInternalServerError
+			log.Printf("request %s failed: %v", problem.RequestID, err)
+		}
+		problem.Title = http.StatusText(problem.Status)
+
+		c.Header("Content-Type", "application/problem+json")
+		c.AbortWithStatusJSON(problem.Status, problem)
+	}
+}
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"golang-crud/models"
+	"golang-crud/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrentPrincipal returns the user and role set in the context by
+// RequirePermission.
+func CurrentPrincipal(c *gin.Context) (*policy.Principal, bool) {
+	user, ok := c.Get("currentUser")
+	if !ok {
+		return nil, false
+	}
+
+	principal := &policy.Principal{User: user.(models.User)}
+	if role, ok := c.Get("currentRole"); ok {
+		principal.Role = role.(*models.Role)
+	}
+	return principal, true
+}
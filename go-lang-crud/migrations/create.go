@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// VersionLayout formats the time a migration is created at into its version.
+const VersionLayout = "20060102150405"
+
+var nameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes the up and down scripts of a new migration to dir,
+// versioned by the current time, and returns their paths.
+func Create(dir, name string, now time.Time) (string, string, error) {
+	name = strings.Trim(nameSeparators.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	base := filepath.Join(dir, now.UTC().Format(VersionLayout)+"_"+name)
+	up, down := base+".up.sql", base+".down.sql"
+	scripts := map[string]string{
+		up:   "-- " + name + ": write the schema change here\n",
+		down: "-- " + name + ": write the statements reverting it here\n",
+	}
+	for path, script := range scripts {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", err
+		}
+		_, err = file.WriteString(script)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockID is the key of the Postgres advisory lock held while migrating, so
+// replicas starting together apply each migration once.
+const lockID int64 = 4820146371
+
+// Status is a migration and when it was applied, nil while pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// appliedMigration is a row of schema_migrations.
+type appliedMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *gorm.DB, done map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&appliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", migration, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *gorm.DB, done map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&appliedMigration{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", migration, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *gorm.DB, done map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			status := Status{Migration: migration}
+			if appliedAt, ok := done[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending lists the migrations not applied yet. Unlike Status it doesn't wait
+// for the migration lock, so it stays cheap enough for health checks.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	var versions []int64
+	if err := m.db.WithContext(ctx).Model(&appliedMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// locked runs fn on a single connection holding the migration lock, with
+// schema_migrations created and the applied versions read.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB, done map[int64]time.Time) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// Advisory locks belong to the session, hence the dedicated connection.
+		// Other databases, such as the SQLite used in tests, run unlocked.
+		if conn.Dialector.Name() == "postgres" {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockID).Error; err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", lockID)
+		}
+
+		if !conn.Migrator().HasTable(&appliedMigration{}) {
+			if err := conn.Migrator().CreateTable(&appliedMigration{}); err != nil {
+				return err
+			}
+		}
+
+		var rows []appliedMigration
+		if err := conn.Find(&rows).Error; err != nil {
+			return err
+		}
+		done := make(map[int64]time.Time, len(rows))
+		for _, row := range rows {
+			done[row.Version] = row.AppliedAt
+		}
+		return fn(conn, done)
+	})
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "external_identities";
+DROP TABLE IF EXISTS "refresh_tokens";
+DROP TABLE IF EXISTS "posts";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "companies";
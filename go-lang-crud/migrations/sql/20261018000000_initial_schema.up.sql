@@ -0,0 +1,115 @@
+-- Baseline schema. Every statement is guarded with IF NOT EXISTS, and the
+-- columns added since the first release are added to existing tables, so
+-- databases created by the former AutoMigrate at startup are brought up to it.
+
+CREATE TABLE IF NOT EXISTS "companies" (
+    "id" bigserial,
+    "name" text,
+    "deleted_at" timestamptz,
+    "version" bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY ("id")
+);
+ALTER TABLE "companies" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+ALTER TABLE "companies" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS "idx_companies_deleted_at" ON "companies" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "deleted_at" timestamptz,
+    "version" bigint NOT NULL DEFAULT 1,
+    "name" varchar(100) NOT NULL,
+    "email" varchar(100) NOT NULL,
+    "password" text NOT NULL,
+    "role" varchar(50) DEFAULT 'user',
+    "company_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_companies_users" FOREIGN KEY ("company_id") REFERENCES "companies" ("id") ON DELETE CASCADE
+);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+-- The first release stored roles in a user_role enum type; roles are rows of
+-- the roles table now, so the column holds their names
+ALTER TABLE "users" ALTER COLUMN "role" DROP DEFAULT;
+ALTER TABLE "users" ALTER COLUMN "role" TYPE varchar(50) USING "role"::text;
+ALTER TABLE "users" ALTER COLUMN "role" SET DEFAULT 'user';
+ALTER TABLE "users" ALTER COLUMN "company_id" DROP NOT NULL;
+DROP TYPE IF EXISTS "user_role";
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+-- Emails are unique among live users only, so trashed users don't hold on to
+-- theirs; AutoMigrate made a unique constraint of the whole column
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "uni_users_email";
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_email_key";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email") WHERE "deleted_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "posts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "title" text,
+    "body" text,
+    "user_id" bigint,
+    "status" varchar(20) NOT NULL DEFAULT 'published',
+    "published_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_posts" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "created_at" timestamptz;
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz;
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "status" varchar(20) NOT NULL DEFAULT 'published';
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "published_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "posts" ("status");
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "family_id" varchar(64) NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "replaced_by" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "external_identities" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "provider" varchar(50) NOT NULL,
+    "subject" varchar(255) NOT NULL,
+    "email" varchar(100),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_identities" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_identity_provider_subject" ON "external_identities" ("provider", "subject");
+CREATE INDEX IF NOT EXISTS "idx_external_identities_user_id" ON "external_identities" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" bigserial,
+    "name" varchar(50) NOT NULL,
+    "description" text,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+    "role_id" bigint,
+    "permission_id" bigint,
+    PRIMARY KEY ("role_id", "permission_id"),
+    CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id") ON DELETE CASCADE
+);
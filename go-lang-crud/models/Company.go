@@ -1,7 +1,13 @@
 package models
 
+import "gorm.io/gorm"
+
+// Company has many users. Deleting a company moves it and its users and
+// their posts to the trash; the cascade constraint applies when purging.
 type Company struct {
-	ID    uint `gorm:"primarykey"`
-	Name  string
-	Users []User `gorm:"constraint:OnDelete:CASCADE;"`
+	ID        uint `gorm:"primarykey"`
+	Name      string
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Version   uint           `gorm:"not null;default:1"`
+	Users     []User         `gorm:"constraint:OnDelete:CASCADE;"`
 }
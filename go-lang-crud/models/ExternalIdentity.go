@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ExternalIdentity links an account at an identity provider to a user, so a
+// user can sign in with a password and any number of providers.
+type ExternalIdentity struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	Provider  string `gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject   string `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"`
+	Email     string `gorm:"size:100"`
+}
@@ -1,8 +1,30 @@
 package models
 
+import (
+	"golang-crud/enum"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Post struct {
-	ID     uint `gorm:"primarykey"`
-	Title  string
-	Body   string
-	UserId uint
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Title     string
+	Body      string
+	UserId    uint
+	// User is the author, only loaded when requested
+	User *User `gorm:"foreignKey:UserId"`
+	// Rows that predate publishing states default to published so they stay
+	// visible; new posts start as drafts (see PostService.CreatePost).
+	Status enum.PostStatus `gorm:"size:20;not null;default:'published';index"`
+	// PublishedAt is when a published post becomes visible; it may be in the future
+	PublishedAt *time.Time
+}
+
+// IsVisible reports whether readers other than the author can see the post at the given time
+func (p *Post) IsVisible(now time.Time) bool {
+	return p.Status == enum.Published && (p.PublishedAt == nil || !p.PublishedAt.After(now))
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RefreshToken is a single link in a rotating refresh-token chain. Every
+// token issued for the same login shares a FamilyID, which doubles as the
+// session ID stamped into access tokens.
+type RefreshToken struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UserID     uint      `gorm:"not null;index"`
+	FamilyID   string    `gorm:"size:64;not null;index"`
+	TokenHash  string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	ReplacedBy *uint
+}
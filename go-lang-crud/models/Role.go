@@ -0,0 +1,26 @@
+package models
+
+import "golang-crud/enum"
+
+// Role is a named set of permissions. Users refer to it through User.Role.
+type Role struct {
+	ID          uint      `gorm:"primarykey"`
+	Name        enum.Role `gorm:"size:50;not null;uniqueIndex"`
+	Description string
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE;"`
+}
+
+// HasPermission reports whether the role grants the permission
+func (r *Role) HasPermission(permission enum.Permission) bool {
+	for _, p := range r.Permissions {
+		if p.Name == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type Permission struct {
+	ID   uint            `gorm:"primarykey"`
+	Name enum.Permission `gorm:"size:100;not null;uniqueIndex"`
+}
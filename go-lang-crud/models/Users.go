@@ -3,17 +3,23 @@ package models
 import (
 	"golang-crud/enum"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // User has many posts
 type User struct {
 	ID        uint `gorm:"primarykey"`
 	CreatedAt time.Time
-	Name      string    `gorm:"size:100;not null"`
-	Email     string    `gorm:"size:100;unique;not null" validate:"required,email"`
-	Password  string    `gorm:"not null" validate:"required,min=8"`
-	Role      enum.Role `gorm:"type:user_role;default:'user'"`
-	CompanyID uint
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Version   uint           `gorm:"not null;default:1"`
+	Name      string         `gorm:"size:100;not null"`
+	Email     string         `gorm:"size:100;not null;uniqueIndex:idx_users_email,where:deleted_at IS NULL"`
+	Password  string         `gorm:"not null" json:"-"`
+	Role      enum.Role      `gorm:"size:50;default:'user'"`
+	CompanyID *uint
 	Company   Company
 	Posts     []Post `gorm:"constraint:OnDelete:CASCADE;"`
+	// Accounts at identity providers this user can sign in with
+	Identities []ExternalIdentity `gorm:"constraint:OnDelete:CASCADE;"`
 }
@@ -0,0 +1,188 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang-crud/custom_error"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/markbates/goth"
+)
+
+// ErrInvalidFlow is returned when a callback doesn't belong to a sign-in
+// started from this browser, or the sign-in expired.
+var ErrInvalidFlow = custom_error.BadRequest("sign-in expired or was started elsewhere, start again")
+
+// flowTTL is how long users have to sign in at their provider.
+const flowTTL = 10 * time.Minute
+
+const flowCookie = "oauth_flow"
+
+// Registry holds the identity providers of one application and runs their
+// sign-in flows, kept between the redirect and the callback in an encrypted
+// cookie.
+type Registry struct {
+	providers map[string]goth.Provider
+	cookies   *securecookie.SecureCookie
+}
+
+// flow is what the cookie carries from Begin to Complete.
+type flow struct {
+	Provider string            `json:"provider"`
+	State    string            `json:"state"`
+	Session  string            `json:"session"`
+	Values   map[string]string `json:"values,omitempty"`
+}
+
+// NewRegistry signs and encrypts flow cookies with keys derived from secret.
+// Without a secret a random one is used, so flows only complete on the
+// instance that started them.
+func NewRegistry(secret string) *Registry {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = securecookie.GenerateRandomKey(32)
+	}
+	hashKey := sha256.Sum256(append([]byte("oauth-flow-hash:"), key...))
+	blockKey := sha256.Sum256(append([]byte("oauth-flow-block:"), key...))
+
+	cookies := securecookie.New(hashKey[:], blockKey[:]).SetSerializer(securecookie.JSONEncoder{})
+	cookies.MaxAge(int(flowTTL.Seconds()))
+	return &Registry{providers: map[string]goth.Provider{}, cookies: cookies}
+}
+
+// Add enables the provider under its name.
+func (r *Registry) Add(provider goth.Provider) {
+	r.providers[provider.Name()] = provider
+}
+
+// Get returns the provider enabled under name.
+func (r *Registry) Get(name string) (goth.Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names returns the names of every enabled provider, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Begin starts signing in with the provider and returns the URL to send the
+// user to. Values are handed back by Complete, untouched by the browser.
+func (r *Registry) Begin(w http.ResponseWriter, req *http.Request, name string, values map[string]string) (string, error) {
+	provider, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown identity provider %q", name)
+	}
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	state := base64.RawURLEncoding.EncodeToString(nonce)
+
+	session, err := provider.BeginAuth(state)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := session.GetAuthURL()
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := r.cookies.Encode(flowCookie, flow{Provider: name, State: state, Session: session.Marshal(), Values: values})
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, flowCookieFor(req, encoded, int(flowTTL.Seconds())))
+	return authURL, nil
+}
+
+// Complete finishes the sign-in started by Begin on the provider's callback,
+// returning the user and the values given to Begin. The flow can't be
+// replayed, the cookie is cleared whatever the outcome.
+func (r *Registry) Complete(w http.ResponseWriter, req *http.Request, name string) (goth.User, map[string]string, error) {
+	provider, ok := r.Get(name)
+	if !ok {
+		return goth.User{}, nil, fmt.Errorf("unknown identity provider %q", name)
+	}
+
+	cookie, err := req.Cookie(flowCookie)
+	if err != nil {
+		return goth.User{}, nil, ErrInvalidFlow
+	}
+	http.SetCookie(w, flowCookieFor(req, "", -1))
+
+	var current flow
+	if err := r.cookies.Decode(flowCookie, cookie.Value, &current); err != nil {
+		return goth.User{}, nil, ErrInvalidFlow
+	}
+	// Providers answering with response_mode=form_post send a form instead
+	params := req.URL.Query()
+	if len(params) == 0 && req.Method == http.MethodPost {
+		if err := req.ParseForm(); err != nil {
+			return goth.User{}, nil, ErrInvalidFlow
+		}
+		params = req.PostForm
+	}
+	state := params.Get("state")
+	if current.Provider != name || subtle.ConstantTimeCompare([]byte(state), []byte(current.State)) != 1 {
+		return goth.User{}, nil, ErrInvalidFlow
+	}
+
+	session, err := provider.UnmarshalSession(current.Session)
+	if err != nil {
+		return goth.User{}, nil, err
+	}
+	if _, err := session.Authorize(provider, params); err != nil {
+		return goth.User{}, nil, err
+	}
+	user, err := provider.FetchUser(session)
+	if err != nil {
+		return goth.User{}, nil, err
+	}
+	return user, current.Values, nil
+}
+
+func flowCookieFor(req *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     flowCookie,
+		Value:    value,
+		Path:     "/auth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https",
+		// Lax still sends the cookie on the provider's redirect back
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// EmailVerified reports whether the provider asserted that the user owns
+// their email, through the email_verified claim of OpenID Connect or the
+// verified_email field of Google's userinfo. Providers asserting neither
+// don't vouch for the email.
+func EmailVerified(user goth.User) bool {
+	for _, key := range []string{"email_verified", "verified_email"} {
+		switch value := user.RawData[key].(type) {
+		case bool:
+			if value {
+				return true
+			}
+		case string:
+			if value == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+// Package oauthtest provides a local OpenID Connect provider for tests.
+package oauthtest
+
+import (
+	"encoding/json"
+	"golang-crud/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// User is the identity the provider signs every user in as.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Server is a local OpenID Connect provider. It approves
+// every authorization request immediately and issues an ID token for User,
+// so the whole /auth/:provider/login -> callback flow runs without network
+// access or real credentials.
+type Server struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	User         User
+}
+
+const mockAuthorizationCode = "mock-authorization-code"
+
+func NewServer(user User) *Server {
+	m := &Server{
+		ClientID:     "mock-client",
+		ClientSecret: "mock-secret",
+		User:         user,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/userinfo", m.userInfo)
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+// URL is the issuer URL of the provider.
+func (m *Server) URL() string {
+	return m.Server.URL
+}
+
+// ProviderConfig returns the configuration that registers the server as an
+// "oidc" provider under name.
+func (m *Server) ProviderConfig(name, callbackURL string) oauth.ProviderConfig {
+	return oauth.ProviderConfig{
+		Name:         name,
+		Type:         "oidc",
+		ClientID:     m.ClientID,
+		ClientSecret: m.ClientSecret,
+		CallbackURL:  callbackURL,
+		DiscoveryURL: m.URL() + "/.well-known/openid-configuration",
+	}
+}
+
+func (m *Server) Close() {
+	m.Server.Close()
+}
+
+func (m *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                 m.URL(),
+		"authorization_endpoint": m.URL() + "/authorize",
+		"token_endpoint":         m.URL() + "/token",
+		"userinfo_endpoint":      m.URL() + "/userinfo",
+	})
+}
+
+func (m *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	redirect, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil || redirect.String() == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	q := redirect.Query()
+	q.Set("code", mockAuthorizationCode)
+	q.Set("state", r.URL.Query().Get("state"))
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != mockAuthorizationCode {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":            m.URL(),
+		"aud":            m.ClientID,
+		"sub":            m.User.Subject,
+		"email":          m.User.Email,
+		"email_verified": m.User.EmailVerified,
+		"name":           m.User.Name,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	})
+	signed, err := idToken.SignedString([]byte(m.ClientSecret))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func (m *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"sub":            m.User.Subject,
+		"email":          m.User.Email,
+		"email_verified": m.User.EmailVerified,
+		"name":           m.User.Name,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"fmt"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/openidConnect"
+)
+
+// ProviderConfig describes one identity provider users can sign in with.
+type ProviderConfig struct {
+	// Name is used in the /auth/:provider routes.
+	Name string
+	// Type selects the factory, e.g. "github" or "oidc".
+	Type         string
+	ClientID     string
+	ClientSecret string
+	CallbackURL  string
+	// DiscoveryURL is the OpenID Connect discovery document, oidc only.
+	DiscoveryURL string
+	Scopes       []string
+}
+
+// Factory builds a goth provider from its configuration.
+type Factory func(config ProviderConfig) (goth.Provider, error)
+
+var factories = map[string]Factory{
+	"google": func(config ProviderConfig) (goth.Provider, error) {
+		return google.New(config.ClientID, config.ClientSecret, config.CallbackURL, scopesOr(config.Scopes, "email", "profile")...), nil
+	},
+	"github": func(config ProviderConfig) (goth.Provider, error) {
+		return github.New(config.ClientID, config.ClientSecret, config.CallbackURL, scopesOr(config.Scopes, "read:user", "user:email")...), nil
+	},
+	"gitlab": func(config ProviderConfig) (goth.Provider, error) {
+		return gitlab.New(config.ClientID, config.ClientSecret, config.CallbackURL, scopesOr(config.Scopes, "read_user")...), nil
+	},
+	"microsoft": func(config ProviderConfig) (goth.Provider, error) {
+		return microsoftonline.New(config.ClientID, config.ClientSecret, config.CallbackURL, config.Scopes...), nil
+	},
+	"oidc": func(config ProviderConfig) (goth.Provider, error) {
+		if config.DiscoveryURL == "" {
+			return nil, fmt.Errorf("provider %s: discovery URL is required", config.Name)
+		}
+		return openidConnect.New(config.ClientID, config.ClientSecret, config.CallbackURL, config.DiscoveryURL, scopesOr(config.Scopes, "openid", "email", "profile")...)
+	},
+}
+
+// RegisterFactory makes a new provider type available to configuration.
+func RegisterFactory(providerType string, factory Factory) {
+	factories[providerType] = factory
+}
+
+// NewProvider builds the provider described by config and names it after
+// config.Name, so several providers of the same type can be enabled at once.
+func NewProvider(config ProviderConfig) (goth.Provider, error) {
+	factory, ok := factories[config.Type]
+	if !ok {
+		return nil, fmt.Errorf("provider %s: unknown type %q", config.Name, config.Type)
+	}
+	if config.ClientID == "" || config.ClientSecret == "" || config.CallbackURL == "" {
+		return nil, fmt.Errorf("provider %s: client ID, client secret and callback URL are required", config.Name)
+	}
+
+	provider, err := factory(config)
+	if err != nil {
+		return nil, err
+	}
+	provider.SetName(config.Name)
+	return provider, nil
+}
+
+func scopesOr(scopes []string, defaults ...string) []string {
+	if len(scopes) > 0 {
+		return scopes
+	}
+	return defaults
+}
@@ -0,0 +1,75 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Column is a column a list is ordered by.
+type Column struct {
+	Name string
+	Desc bool
+}
+
+func (c Column) String() string {
+	if c.Desc {
+		return "-" + c.Name
+	}
+	return c.Name
+}
+
+// cursor marks the row a page starts after (or ends before) by the values of
+// its order columns. The order is kept so a cursor can't be replayed against
+// a differently sorted list.
+type cursor struct {
+	Order  string        `json:"o"`
+	Values []interface{} `json:"v"`
+}
+
+func orderKey(order []Column) string {
+	names := make([]string, len(order))
+	for i, column := range order {
+		names[i] = column.String()
+	}
+	return strings.Join(names, ",")
+}
+
+func encodeCursor(order []Column, values []interface{}) (string, error) {
+	data, err := json.Marshal(cursor{Order: orderKey(order), Values: values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string, order []Column) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidParams)
+	}
+
+	var decoded cursor
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidParams)
+	}
+	if decoded.Order != orderKey(order) || len(decoded.Values) != len(order) {
+		return nil, fmt.Errorf("%w: cursor does not match the sort order", ErrInvalidParams)
+	}
+
+	for i, value := range decoded.Values {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		if integer, err := number.Int64(); err == nil {
+			decoded.Values[i] = integer
+		} else if float, err := number.Float64(); err == nil {
+			decoded.Values[i] = float
+		}
+	}
+	return decoded.Values, nil
+}
@@ -0,0 +1,187 @@
+package pagination
+
+import (
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page is one page of a keyset-paginated list.
+type Page[T any] struct {
+	Items []T
+	Total int64
+	Limit int
+	// Next and Prev are the cursors of the neighbouring pages, empty at either end
+	Next string
+	Prev string
+}
+
+// Find loads the page of query described by params. The order must be unique,
+// so it always ends with the primary key; an "id" column is appended when missing.
+// The scopes only apply to loading the items, not to counting them.
+func Find[T any](query *gorm.DB, params Params, order []Column, scopes ...func(*gorm.DB) *gorm.DB) (*Page[T], error) {
+	if !slices.ContainsFunc(order, func(column Column) bool { return column.Name == "id" }) {
+		order = append(slices.Clone(order), Column{Name: "id"})
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultLimit
+	}
+
+	// Walking backwards reverses the order, the page is flipped back afterwards
+	backward := params.Before != ""
+	cursorValue := params.After
+	if backward {
+		cursorValue = params.Before
+	}
+	var values []interface{}
+	if cursorValue != "" {
+		var err error
+		if values, err = decodeCursor(cursorValue, order); err != nil {
+			return nil, err
+		}
+	}
+
+	page := &Page[T]{Limit: params.Limit}
+	if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	query = query.Session(&gorm.Session{})
+	if values != nil {
+		query = query.Where(seek(order, values, backward))
+	}
+	for _, column := range order {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column.Name}, Desc: column.Desc != backward})
+	}
+
+	result := query.Scopes(scopes...).Limit(params.Limit + 1).Find(&page.Items)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	more := len(page.Items) > params.Limit
+	if more {
+		page.Items = page.Items[:params.Limit]
+	}
+	if backward {
+		slices.Reverse(page.Items)
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	hasNext, hasPrev := more, cursorValue != ""
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	var err error
+	if hasNext {
+		if page.Next, err = cursorOf(result, order, page.Items[len(page.Items)-1]); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if page.Prev, err = cursorOf(result, order, page.Items[0]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// seek selects the rows after the cursor values in the given order:
+// (a > ?) OR (a = ? AND b > ?) OR ..., flipping each comparison for
+// descending columns and for backward paging.
+func seek(order []Column, values []interface{}, backward bool) clause.Expression {
+	alternatives := make([]clause.Expression, 0, len(order))
+	for i, column := range order {
+		conditions := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Name: order[j].Name}, Value: values[j]})
+		}
+
+		boundary := clause.Column{Name: column.Name}
+		if column.Desc != backward {
+			conditions = append(conditions, clause.Lt{Column: boundary, Value: values[i]})
+		} else {
+			conditions = append(conditions, clause.Gt{Column: boundary, Value: values[i]})
+		}
+		alternatives = append(alternatives, clause.And(conditions...))
+	}
+	return clause.Or(alternatives...)
+}
+
+func cursorOf(result *gorm.DB, order []Column, item interface{}) (string, error) {
+	values := make([]interface{}, len(order))
+	row := reflect.Indirect(reflect.ValueOf(item))
+	for i, column := range order {
+		field := result.Statement.Schema.LookUpField(column.Name)
+		if field == nil {
+			return "", gorm.ErrInvalidField
+		}
+		values[i], _ = field.ValueOf(result.Statement.Context, row)
+	}
+	return encodeCursor(order, values)
+}
+
+// Map converts the items of a page, keeping its counts and cursors.
+func Map[T, U any](page *Page[T], convert func(T) U) *Page[U] {
+	items := make([]U, len(page.Items))
+	for i, item := range page.Items {
+		items[i] = convert(item)
+	}
+	return &Page[U]{Items: items, Total: page.Total, Limit: page.Limit, Next: page.Next, Prev: page.Prev}
+}
+
+// Envelope is the JSON shape every paginated list is returned in.
+type Envelope[T any] struct {
+	Data  []T   `json:"data"`
+	Meta  Meta  `json:"meta"`
+	Links Links `json:"links"`
+}
+
+type Meta struct {
+	Total int64 `json:"total"`
+	Limit int   `json:"limit"`
+}
+
+type Links struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Envelope wraps the page for the response to the request at requestURL,
+// keeping its other query parameters in the next and prev links.
+func (p *Page[T]) Envelope(requestURL *url.URL) Envelope[T] {
+	data := p.Items
+	if data == nil {
+		data = []T{}
+	}
+	return Envelope[T]{
+		Data:  data,
+		Meta:  Meta{Total: p.Total, Limit: p.Limit},
+		Links: Links{Self: link(requestURL, p.Limit, "", ""), Next: link(requestURL, p.Limit, "after", p.Next), Prev: link(requestURL, p.Limit, "before", p.Prev)},
+	}
+}
+
+func link(requestURL *url.URL, limit int, param, cursor string) string {
+	if param != "" && cursor == "" {
+		return ""
+	}
+
+	query := requestURL.Query()
+	if param != "" {
+		query.Del("after")
+		query.Del("before")
+		query.Set(param, cursor)
+	}
+	query.Set("limit", strconv.Itoa(limit))
+
+	u := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
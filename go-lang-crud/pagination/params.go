@@ -0,0 +1,47 @@
+package pagination
+
+import (
+	"fmt"
+	"golang-crud/custom_error"
+	"net/url"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidParams is returned for malformed pagination query parameters.
+var ErrInvalidParams = custom_error.BadRequest("invalid pagination parameters")
+
+// Params are the pagination parameters of a list request: the page size and
+// an optional cursor to continue after or before.
+type Params struct {
+	Limit  int
+	After  string
+	Before string
+}
+
+// ParseQuery reads the limit, after and before query parameters.
+func ParseQuery(query url.Values) (Params, error) {
+	params := Params{
+		Limit:  DefaultLimit,
+		After:  query.Get("after"),
+		Before: query.Get("before"),
+	}
+
+	if params.After != "" && params.Before != "" {
+		return params, fmt.Errorf("%w: after and before cannot be combined", ErrInvalidParams)
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return params, fmt.Errorf("%w: limit must be a positive number", ErrInvalidParams)
+		}
+		params.Limit = min(limit, MaxLimit)
+	}
+
+	return params, nil
+}
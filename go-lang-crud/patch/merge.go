@@ -0,0 +1,40 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Merge applies a JSON merge patch to doc: members of the patch replace
+// those of the document, objects are merged recursively and null removes
+// a member.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, changes interface{}) interface{} {
+	patch, ok := changes.(map[string]interface{})
+	if !ok {
+		return changes
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(object, key)
+		} else {
+			object[key] = merge(object[key], value)
+		}
+	}
+	return object
+}
@@ -0,0 +1,262 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is one step of a JSON patch. Value is nil when absent, so an
+// explicit null can be told apart from a missing value.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyOperations applies a JSON patch to doc. The operations apply in
+// order and the patch fails as a whole if any of them fails.
+func ApplyOperations(doc []byte, operations []Operation) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		var err error
+		target, err = operation.apply(target)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (o Operation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		switch o.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			return set(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: %s", ErrTestFailed, o.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		if o.Op == "move" {
+			if strings.HasPrefix(o.Path, o.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalidPatch, o.From)
+			}
+			doc, value, err := remove(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, o.Op)
+}
+
+func (o Operation) value() (interface{}, error) {
+	if o.Value == nil {
+		return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidPatch, o.Op)
+	}
+	var value interface{}
+	if err := json.Unmarshal(o.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// pointer is a parsed JSON pointer (RFC 6901), one unescaped token per level.
+type pointer []string
+
+var unescape = strings.NewReplacer("~1", "/", "~0", "~")
+
+func parsePointer(path string) (pointer, error) {
+	if path == "" {
+		return pointer{}, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w: malformed path %q", ErrInvalidPatch, path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = unescape.Replace(token)
+	}
+	return tokens, nil
+}
+
+func (p pointer) String() string {
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	var path strings.Builder
+	for _, token := range p {
+		path.WriteString("/" + escape.Replace(token))
+	}
+	return path.String()
+}
+
+func get(doc interface{}, path pointer) (interface{}, error) {
+	for i, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path[:i+1])
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", err, path[:i+1])
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+// set replaces the value at path, which must have a parent container,
+// returning the updated document.
+func set(doc interface{}, path pointer, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	key := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[key] = value
+	case []interface{}:
+		index, err := arrayIndex(key, len(container)-1)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, path)
+		}
+		container[index] = value
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+	return doc, nil
+}
+
+// add adds a member to an object, replacing any existing one, or inserts an
+// element into an array, "-" appending it.
+func add(doc interface{}, path pointer, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parentPath, key := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	array, ok := parent.([]interface{})
+	if !ok {
+		return set(doc, path, value)
+	}
+	index := len(array)
+	if key != "-" {
+		if index, err = arrayIndex(key, len(array)); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, path)
+		}
+	}
+	inserted := append(array[:index:index], append([]interface{}{value}, array[index:]...)...)
+	return set(doc, parentPath, inserted)
+}
+
+// remove removes the value at path, returning the updated document and the
+// removed value.
+func remove(doc interface{}, path pointer) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	value, err := get(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parentPath, key := path[:len(path)-1], path[len(path)-1]
+	parent, _ := get(doc, parentPath)
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		delete(container, key)
+	case []interface{}:
+		index, _ := arrayIndex(key, len(container)-1)
+		doc, err = set(doc, parentPath, append(container[:index:index], container[index+1:]...))
+	}
+	return doc, value, err
+}
+
+// arrayIndex parses an array index token, which must be a non-negative
+// number without leading zeros no greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, ErrPathNotFound
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, ErrPathNotFound
+	}
+	return index, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, member := range value {
+			copied[key] = deepCopy(member)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, element := range value {
+			copied[i] = deepCopy(element)
+		}
+		return copied
+	}
+	return value
+}
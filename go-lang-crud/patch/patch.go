@@ -0,0 +1,71 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to the JSON representation of a resource.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-crud/custom_error"
+	"mime"
+	"reflect"
+	"sort"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned for patch documents that are not well formed.
+	ErrInvalidPatch = custom_error.BadRequest("invalid patch")
+	// ErrUnsupportedType is returned for bodies that are neither kind of patch.
+	ErrUnsupportedType = custom_error.New(custom_error.KindUnsupportedMediaType, "patch must be "+MergePatchType+" or "+JSONPatchType)
+	// ErrPathNotFound is returned for operations on locations the document doesn't have.
+	ErrPathNotFound = custom_error.Validation("patch path does not exist", nil)
+	// ErrTestFailed is returned when a test operation doesn't match the document.
+	ErrTestFailed = custom_error.Conflict("patch test failed")
+)
+
+// Apply applies body, a patch of the given content type, to doc. Plain
+// application/json bodies are treated as merge patches.
+func Apply(contentType string, doc, body []byte) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case MergePatchType, "application/json":
+		return Merge(doc, body)
+	case JSONPatchType:
+		var operations []Operation
+		if err := json.Unmarshal(body, &operations); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return ApplyOperations(doc, operations)
+	}
+	return nil, ErrUnsupportedType
+}
+
+// Changed lists, in order, the top-level members that differ between two
+// JSON objects, including members only one of them has.
+func Changed(before, after []byte) ([]string, error) {
+	var old, updated map[string]interface{}
+	if err := json.Unmarshal(before, &old); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &updated); err != nil {
+		return nil, fmt.Errorf("%w: the patched document must be an object", ErrInvalidPatch)
+	}
+
+	var changed []string
+	for key, value := range updated {
+		if previous, ok := old[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range old {
+		if _, ok := updated[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
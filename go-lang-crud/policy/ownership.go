@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"golang-crud/enum"
+	"golang-crud/models"
+)
+
+// Principal is the authenticated user a request acts as, together with
+// the role that decides what they may do.
+type Principal struct {
+	User models.User
+	Role *models.Role
+}
+
+// Can reports whether the principal's role grants the permission.
+func (p *Principal) Can(permission enum.Permission) bool {
+	return p.Role != nil && p.Role.HasPermission(permission)
+}
+
+// CanUpdateUser allows users to update their own profile and holders of
+// users:manage to update the profile of anyone whose role grants nothing
+// they lack, given as the target's current role; otherwise a tenant admin
+// could take over a super admin's account by changing its password.
+func CanUpdateUser(p *Principal, userID uint, role *models.Role) bool {
+	return p.User.ID == userID || p.Can(enum.UsersManage) && CanAssignRole(p, role)
+}
+
+// CanActAsAuthor allows users to write posts as themselves and holders of
+// posts:manage to write them on behalf of anyone.
+func CanActAsAuthor(p *Principal, authorID uint) bool {
+	return p.User.ID == authorID || p.Can(enum.PostsManage)
+}
+
+// CanModifyPost allows a post to be edited or deleted by its author and by
+// holders of posts:manage.
+func CanModifyPost(p *Principal, post *models.Post) bool {
+	return CanActAsAuthor(p, post.UserId)
+}
+
+// CanAssignRole allows a role to be given out only by principals who hold
+// every permission it grants, so nobody can create users more powerful
+// than themselves (e.g. a tenant admin creating a super admin).
+func CanAssignRole(p *Principal, role *models.Role) bool {
+	if role == nil {
+		return false
+	}
+	for _, permission := range role.Permissions {
+		if !p.Can(permission.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanPatchUserField decides which fields of a profile a principal may patch,
+// role being the user's current role: see CanUpdateUser for the name, email
+// and password, only holders of users:manage may change the role of users
+// whose role grants nothing they lack, and moving such a user to another
+// company also requires tenants:all.
+func CanPatchUserField(p *Principal, userID uint, role *models.Role, field string) bool {
+	switch field {
+	case "name", "email", "password":
+		return CanUpdateUser(p, userID, role)
+	case "role":
+		return p.Can(enum.UsersManage) && CanAssignRole(p, role)
+	case "company_id":
+		return p.Can(enum.UsersManage) && p.Can(enum.TenantsAll) && CanAssignRole(p, role)
+	}
+	return false
+}
@@ -1,7 +1,15 @@
 package repository
 
 import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
+	"golang-crud/tenant"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,16 +22,131 @@ func NewCompanyRepository(db *gorm.DB) *CompanyRepository {
 	return &CompanyRepository{DB: db}
 }
 
-func (r *CompanyRepository) Create(company *models.Company) error {
-	return r.DB.Create(company).Error
+func (r *CompanyRepository) Create(ctx context.Context, company *models.Company) error {
+	return violation(r.DB.WithContext(ctx).Create(company).Error)
 }
 
-func (r *CompanyRepository) FindAll() ([]models.Company, error) {
-	var companies []models.Company
-	err := r.DB.Find(&companies).Error
-	return companies, err
+// CompanyQuery lists the fields companies can be filtered and sorted by.
+var CompanyQuery = filter.Resource{
+	Fields: map[string]filter.Field{
+		"id":   {Column: "id", Type: filter.Number, Sortable: true},
+		"name": {Column: "name", Type: filter.String, Sortable: true},
+	},
+	DefaultSort: []pagination.Column{{Name: "id"}},
 }
 
-func (r *CompanyRepository) DeleteById(id string) error {
-	return r.DB.Delete(&models.Company{}, id).Error
+// CompanyFields lists the fields and associations a company response can be limited to.
+var CompanyFields = fieldset.Resource{
+	Fields: map[string]fieldset.Field{
+		"id":   {Column: "id", Key: "id"},
+		"name": {Column: "name", Key: "name"},
+	},
+	Includes: map[string]fieldset.Include{
+		"users": {Association: "Users", Key: "users", Permission: enum.UsersList},
+	},
+	// The ETag is built from the version
+	Required: []string{"id", "version"},
+}
+
+// FindAll lists the companies matching query a page at a time.
+func (r *CompanyRepository) FindAll(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.Company], error) {
+	companies := r.scoped(ctx).Scopes(query.Scope)
+	return pagination.Find[models.Company](companies, params, query.Sort, selection.Scope(columns(query.Sort)...))
+}
+
+func (r *CompanyRepository) FindById(ctx context.Context, id string, selection *fieldset.Selection) (*models.Company, error) {
+	var company models.Company
+	err := r.scoped(ctx).Scopes(selection.Scope()).First(&company, "id = ?", id).Error
+	if err != nil {
+		return nil, translate(err, custom_error.ErrCompanyNotFound)
+	}
+	return &company, nil
+}
+
+func (r *CompanyRepository) Update(ctx context.Context, company *models.Company, data map[string]interface{}) error {
+	return updateVersioned(r.scoped(ctx), company, &company.Version, data)
+}
+
+// DeleteById moves the company, its users and their posts to the trash, if
+// the company is still at version. It returns ErrPreconditionFailed if the
+// company changed since.
+func (r *CompanyRepository) DeleteById(ctx context.Context, id string, version uint) error {
+	company, err := r.FindById(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(company).Where("version = ?", version).Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return custom_error.ErrPreconditionFailed
+		}
+
+		users := tx.Model(&models.User{}).Select("id").Where("company_id = ?", company.ID)
+		if err := tx.Model(&models.Post{}).Where("user_id IN (?)", users).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("company_id = ?", company.ID).Update("deleted_at", now).Error
+	})
+}
+
+// FindUsers lists the members of a company.
+func (r *CompanyRepository) FindUsers(ctx context.Context, id string) ([]models.User, error) {
+	company, err := r.FindById(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	err = r.DB.WithContext(ctx).Where("company_id = ?", company.ID).Find(&users).Error
+	return users, err
+}
+
+// AssignUser moves a user into the company, bumping the user's version as
+// their company_id changes.
+func (r *CompanyRepository) AssignUser(ctx context.Context, id string, userId string) error {
+	company, err := r.FindById(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+
+	result := r.DB.WithContext(ctx).Model(&models.User{}).Scopes(tenant.Users(ctx)).
+		Where("id = ?", userId).
+		Updates(map[string]interface{}{"company_id": company.ID, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return translate(result.Error, custom_error.ErrUserNotFound)
+	}
+	if result.RowsAffected == 0 {
+		return custom_error.ErrUserNotFound
+	}
+	return nil
+}
+
+// RemoveUser takes a user out of the company, leaving them without one and
+// bumping their version.
+func (r *CompanyRepository) RemoveUser(ctx context.Context, id string, userId string) error {
+	company, err := r.FindById(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+
+	result := r.DB.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND company_id = ?", userId, company.ID).
+		Updates(map[string]interface{}{"company_id": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return translate(result.Error, custom_error.ErrUserNotFound)
+	}
+	if result.RowsAffected == 0 {
+		return custom_error.ErrUserNotFound
+	}
+	return nil
+}
+
+// scoped limits queries to the tenant of the request.
+func (r *CompanyRepository) scoped(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Scopes(tenant.Companies(ctx))
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"golang-crud/custom_error"
+	"golang-crud/validation"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	invalidTextRepresentation = "22P02"
+	foreignKeyViolation       = "23503"
+	uniqueViolation           = "23505"
+)
+
+// Postgres names the offending columns in the error detail,
+// e.g. `Key (email)=(jane@example.com) already exists.`
+var violatedKey = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// translate maps GORM errors to domain errors, returning notFound for a
+// missing record or an ID that isn't a number. Other errors are passed
+// through violation.
+func translate(err error, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == invalidTextRepresentation {
+		return notFound
+	}
+	return violation(err)
+}
+
+// violation maps constraint violations on writes to domain errors naming the
+// offending fields: 409 for a duplicate value and 422 for a reference to a
+// missing record. Other errors are passed through unchanged.
+func violation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case uniqueViolation:
+		fields := violatedFields(pgErr, "unique", "is already taken")
+		return &custom_error.Error{
+			Kind:    custom_error.KindConflict,
+			Message: fields[0].Field + " already exists",
+			Details: fields,
+			Err:     err,
+		}
+	case foreignKeyViolation:
+		if strings.Contains(pgErr.Detail, "is still referenced") {
+			return custom_error.Wrap(custom_error.KindConflict, "Record is still referenced by other records", err)
+		}
+		return &custom_error.Error{
+			Kind:    custom_error.KindValidation,
+			Message: "Validation failed",
+			Details: violatedFields(pgErr, "exists", "does not exist"),
+			Err:     err,
+		}
+	}
+	return err
+}
+
+// violatedFields lists the columns named in the error, falling back to the
+// constraint name when Postgres doesn't give the key.
+func violatedFields(pgErr *pgconn.PgError, rule, message string) validation.Errors {
+	columns := []string{pgErr.ConstraintName}
+	if match := violatedKey.FindStringSubmatch(pgErr.Detail); match != nil {
+		columns = strings.Split(match[1], ", ")
+	}
+
+	fields := make(validation.Errors, len(columns))
+	for i, column := range columns {
+		fields[i] = validation.FieldError{Field: column, Rule: rule, Message: message}
+	}
+	return fields
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"golang-crud/custom_error"
+	"golang-crud/models"
+
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository struct {
+	DB *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{DB: db}
+}
+
+func (r *ExternalIdentityRepository) Create(identity *models.ExternalIdentity) error {
+	return violation(r.DB.Create(identity).Error)
+}
+
+func (r *ExternalIdentityRepository) FindByProviderSubject(provider, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, translate(err, custom_error.ErrIdentityNotFound)
+	}
+	return &identity, nil
+}
+
+func (r *ExternalIdentityRepository) FindByUserId(userId uint) ([]models.ExternalIdentity, error) {
+	var identities []models.ExternalIdentity
+	err := r.DB.Where("user_id = ?", userId).Find(&identities).Error
+	return identities, err
+}
@@ -1,7 +1,11 @@
 package repository
 
 import (
+	"context"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,34 +14,34 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *models.User) (*models.User, error) {
-	args := m.Called(user)
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindAll() ([]models.User, error) {
-	args := m.Called()
+func (m *MockUserRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindById(id string) (*models.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepository) FindById(ctx context.Context, id string, selection *fieldset.Selection) (*models.User, error) {
+	args := m.Called(ctx, id, selection)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *models.User, data map[string]interface{}) error {
-	args := m.Called(user, data)
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User, data map[string]interface{}) error {
+	args := m.Called(ctx, user, data)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id string) error {
-	args := m.Called(id)
+func (m *MockUserRepository) Delete(ctx context.Context, id string, version uint) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Paginate(offset, pageSize int) ([]models.User, error) {
-	args := m.Called(offset, pageSize)
-	return args.Get(0).([]models.User), args.Error(1)
+func (m *MockUserRepository) Paginate(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.User], error) {
+	args := m.Called(ctx, query, selection, params)
+	return args.Get(0).(*pagination.Page[models.User]), args.Error(1)
 }
 
 func (r *MockUserRepository) MultipleUpdateSaveTransaction(user *models.User) (*models.User, error) {
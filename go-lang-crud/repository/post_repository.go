@@ -2,7 +2,15 @@
 package repository
 
 import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
+	"golang-crud/tenant"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,18 +23,102 @@ func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{DB: db}
 }
 
-func (r *PostRepository) Create(post *models.Post) error {
-	return r.DB.Create(post).Error
+// Create saves the post if its author belongs to the request's tenant.
+func (r *PostRepository) Create(ctx context.Context, post *models.Post) error {
+	var authors int64
+	err := r.DB.WithContext(ctx).Model(&models.User{}).Scopes(tenant.Users(ctx)).
+		Where("id = ?", post.UserId).Count(&authors).Error
+	if err != nil {
+		return err
+	}
+	if authors == 0 {
+		return custom_error.ErrUserNotFound
+	}
+	// The author is referenced by UserId, never created or updated through a post
+	return violation(r.DB.WithContext(ctx).Omit("User").Create(post).Error)
 }
 
-func (r *PostRepository) FindByUserId(userId string) ([]models.Post, error) {
-	var posts []models.Post
-	err := r.DB.Where("user_id = ?", userId).Find(&posts).Error
-	return posts, err
+// PostFields lists the fields and associations a post response can be limited to.
+var PostFields = fieldset.Resource{
+	Fields: map[string]fieldset.Field{
+		"id":           {Column: "id", Key: "id"},
+		"title":        {Column: "title", Key: "title"},
+		"body":         {Column: "body", Key: "body"},
+		"user_id":      {Column: "user_id", Key: "user_id"},
+		"status":       {Column: "status", Key: "status"},
+		"published_at": {Column: "published_at", Key: "published_at"},
+		"created_at":   {Column: "created_at", Key: "created_at"},
+		"updated_at":   {Column: "updated_at", Key: "updated_at"},
+	},
+	Includes: map[string]fieldset.Include{
+		"user": {Association: "User", Key: "user", ForeignKey: "user_id", Permission: enum.UsersRead},
+	},
+	// Who may see a post depends on its author, status and publish time
+	Required: []string{"id", "user_id", "status", "published_at"},
 }
 
-func (r *PostRepository) FindById(id string) (*models.Post, error) {
+// PostQuery lists the fields posts can be filtered and sorted by.
+var PostQuery = filter.Resource{
+	Fields: map[string]filter.Field{
+		"title":        {Column: "title", Type: filter.String, Sortable: true},
+		"body":         {Column: "body", Type: filter.String},
+		"status":       {Column: "status", Type: filter.String, Sortable: true},
+		"published_at": {Column: "published_at", Type: filter.Time},
+		"created_at":   {Column: "created_at", Type: filter.Time, Sortable: true},
+		"updated_at":   {Column: "updated_at", Type: filter.Time, Sortable: true},
+	},
+	DefaultSort: []pagination.Column{{Name: "created_at", Desc: true}, {Name: "id", Desc: true}},
+}
+
+// FindByUserId lists a page of the user's posts matching query. Unless includeUnpublished
+// is set only posts that are published and past their publish time are returned.
+func (r *PostRepository) FindByUserId(ctx context.Context, userId string, includeUnpublished bool, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.Post], error) {
+	posts := r.scoped(ctx).Where("user_id = ?", userId).Scopes(query.Scope)
+	if !includeUnpublished {
+		posts = posts.Scopes(visible(time.Now()))
+	}
+	return pagination.Find[models.Post](posts, params, query.Sort, selection.Scope(columns(query.Sort)...))
+}
+
+func (r *PostRepository) FindById(ctx context.Context, id string, selection *fieldset.Selection) (*models.Post, error) {
 	var post models.Post
-	err := r.DB.Preload("User").First(&post, "id = ?", id).Error
-	return &post, err
+	err := r.scoped(ctx).Scopes(selection.Scope()).First(&post, "id = ?", id).Error
+	if err != nil {
+		return nil, translate(err, custom_error.ErrPostNotFound)
+	}
+	return &post, nil
+}
+
+func (r *PostRepository) Update(ctx context.Context, post *models.Post, data map[string]interface{}) error {
+	return violation(r.scoped(ctx).Model(post).Updates(data).Error)
+}
+
+func (r *PostRepository) Delete(ctx context.Context, post *models.Post) error {
+	return r.scoped(ctx).Delete(post).Error
+}
+
+// scoped limits queries to the tenant of the request.
+func (r *PostRepository) scoped(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Scopes(tenant.Posts(ctx))
+}
+
+// visible limits a query to posts readers other than the author may see.
+func visible(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.status = ? AND (posts.published_at IS NULL OR posts.published_at <= ?)", enum.Published, now)
+	}
+}
+
+// publishedPosts limits posts to the ones visible right now.
+func publishedPosts(db *gorm.DB) *gorm.DB {
+	return visible(time.Now())(db)
+}
+
+// columns lists the columns of a sort order.
+func columns(order []pagination.Column) []string {
+	names := make([]string, len(order))
+	for i, column := range order {
+		names[i] = column.Name
+	}
+	return names
 }
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"golang-crud/custom_error"
+	"golang-crud/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *RefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.DB.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, translate(err, custom_error.ErrInvalidRefreshToken)
+	}
+	return &token, nil
+}
+
+// Rotate revokes the old token and stores its successor in one transaction.
+// It fails if the old token was revoked concurrently, so a refresh token can
+// only ever be exchanged once.
+func (r *RefreshTokenRepository) Rotate(old *models.RefreshToken, next *models.RefreshToken) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Lost a race with another refresh of the same token
+			return custom_error.ErrRefreshTokenReused
+		}
+		return nil
+	})
+}
+
+// RevokeFamily revokes every token issued for a session.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// IsFamilyActive reports whether a session still has a usable refresh token.
+func (r *RefreshTokenRepository) IsFamilyActive(familyID string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{DB: db}
+}
+
+func (r *RoleRepository) Create(role *models.Role) error {
+	return violation(r.DB.Create(role).Error)
+}
+
+func (r *RoleRepository) FindAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.DB.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) FindByName(name enum.Role) (*models.Role, error) {
+	var role models.Role
+	err := r.DB.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, translate(err, custom_error.ErrRoleNotFound)
+	}
+	return &role, nil
+}
+
+// Update saves the description and replaces the role's permission set.
+func (r *RoleRepository) Update(role *models.Role, permissions []models.Permission) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(role).Update("description", role.Description).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Association("Permissions").Replace(permissions)
+	})
+}
+
+func (r *RoleRepository) Delete(role *models.Role) error {
+	return r.DB.Select("Permissions").Delete(role).Error
+}
+
+func (r *RoleRepository) FindAllPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.DB.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *RoleRepository) FindPermissionsByName(names []enum.Permission) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.DB.Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
+
+// CountUsers returns how many users are assigned the role.
+func (r *RoleRepository) CountUsers(name enum.Role) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/models"
+	"golang-crud/tenant"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TrashRepository struct {
+	DB *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *TrashRepository {
+	return &TrashRepository{DB: db}
+}
+
+func (r *TrashRepository) FindDeletedUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := r.trashed(ctx).Scopes(tenant.Users(ctx)).Order("deleted_at DESC").Find(&users).Error
+	return users, err
+}
+
+func (r *TrashRepository) FindDeletedPosts(ctx context.Context) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.trashed(ctx).Scopes(tenant.Posts(ctx)).Order("deleted_at DESC").Find(&posts).Error
+	return posts, err
+}
+
+func (r *TrashRepository) FindDeletedCompanies(ctx context.Context) ([]models.Company, error) {
+	var companies []models.Company
+	err := r.trashed(ctx).Scopes(tenant.Companies(ctx)).Order("deleted_at DESC").Find(&companies).Error
+	return companies, err
+}
+
+// RestoreCompany restores a company with the users and posts trashed along with it.
+func (r *TrashRepository) RestoreCompany(ctx context.Context, id string) error {
+	var company models.Company
+	if err := r.trashed(ctx).Scopes(tenant.Companies(ctx)).First(&company, "id = ?", id).Error; err != nil {
+		return translate(err, custom_error.ErrCompanyNotFound)
+	}
+	at := company.DeletedAt.Time
+
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := tx.Unscoped().Model(&models.User{}).Select("id").
+			Where("company_id = ? AND deleted_at = ?", company.ID, at)
+		if err := tx.Unscoped().Model(&models.Post{}).Where("user_id IN (?) AND deleted_at = ?", users, at).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		// Fails if someone took the email of a trashed user in the meantime
+		if err := tx.Unscoped().Model(&models.User{}).Where("company_id = ? AND deleted_at = ?", company.ID, at).Update("deleted_at", nil).Error; err != nil {
+			return violation(err)
+		}
+		return tx.Unscoped().Model(&company).Update("deleted_at", nil).Error
+	})
+}
+
+// RestoreUser restores a user with the posts trashed along with them. Users
+// of a trashed company have to be restored through the company.
+func (r *TrashRepository) RestoreUser(ctx context.Context, id string) error {
+	var user models.User
+	if err := r.trashed(ctx).Scopes(tenant.Users(ctx)).First(&user, "id = ?", id).Error; err != nil {
+		return translate(err, custom_error.ErrUserNotFound)
+	}
+
+	if user.CompanyID != nil {
+		var companies int64
+		if err := r.DB.WithContext(ctx).Model(&models.Company{}).Where("id = ?", *user.CompanyID).Count(&companies).Error; err != nil {
+			return err
+		}
+		if companies == 0 {
+			return custom_error.ErrParentDeleted
+		}
+	}
+	at := user.DeletedAt.Time
+
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ? AND deleted_at = ?", user.ID, at).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		// Fails if someone took their email in the meantime
+		return violation(tx.Unscoped().Model(&user).Update("deleted_at", nil).Error)
+	})
+}
+
+// RestorePost restores a post whose author is not in the trash.
+func (r *TrashRepository) RestorePost(ctx context.Context, id string) error {
+	var post models.Post
+	if err := r.trashed(ctx).Scopes(tenant.Posts(ctx)).First(&post, "id = ?", id).Error; err != nil {
+		return translate(err, custom_error.ErrPostNotFound)
+	}
+
+	var authors int64
+	if err := r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", post.UserId).Count(&authors).Error; err != nil {
+		return err
+	}
+	if authors == 0 {
+		return custom_error.ErrParentDeleted
+	}
+
+	return r.DB.WithContext(ctx).Unscoped().Model(&post).Update("deleted_at", nil).Error
+}
+
+// Purge permanently deletes everything trashed before the cutoff within the
+// tenant scope of ctx and returns how many rows were removed.
+func (r *TrashRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tables := []struct {
+			model interface{}
+			scope func(*gorm.DB) *gorm.DB
+		}{
+			{&models.Post{}, tenant.Posts(ctx)},
+			{&models.User{}, tenant.Users(ctx)},
+			{&models.Company{}, tenant.Companies(ctx)},
+		}
+		for _, table := range tables {
+			result := tx.Unscoped().Scopes(table.scope).Where("deleted_at < ?", before).Delete(table.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			purged += result.RowsAffected
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// trashed selects only soft-deleted rows.
+func (r *TrashRepository) trashed(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL")
+}
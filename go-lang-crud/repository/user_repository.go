@@ -1,16 +1,24 @@
 // repository/user_repository_interface.go
 package repository
 
-import "golang-crud/models"
+import (
+	"context"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
+	"golang-crud/models"
+	"golang-crud/pagination"
+)
 
 // UserRepository defines the methods for user repository operations.
+// Methods taking a context are limited to the tenant scope it carries, a nil
+// selection loads every column and no associations.
 type UserRepository interface {
-	Create(user *models.User) (*models.User, error)
-	FindAll() ([]models.User, error)
-	FindById(id string) (*models.User, error)
-	Update(user *models.User, data map[string]interface{}) error
-	Delete(id string) error
-	Paginate(offset, limit int) ([]models.User, error)
+	Create(ctx context.Context, user *models.User) (*models.User, error)
+	FindAll(ctx context.Context) ([]models.User, error)
+	FindById(ctx context.Context, id string, selection *fieldset.Selection) (*models.User, error)
+	Update(ctx context.Context, user *models.User, data map[string]interface{}) error
+	Delete(ctx context.Context, id string, version uint) error
+	Paginate(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.User], error)
 	MultipleUpdateSaveTransaction(user *models.User) (*models.User, error)
-	FindByEmail(email string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
 }
@@ -2,8 +2,16 @@
 package repository
 
 import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
+	"golang-crud/tenant"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,35 +26,91 @@ func NewUserRepository(db *gorm.DB) *UserRepositoryImpl {
 }
 
 // Implement the UserRepository interface
-func (r *UserRepositoryImpl) Create(user *models.User) (*models.User, error) {
-	err := r.DB.Create(user).Error
-	return user, err
+func (r *UserRepositoryImpl) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	err := r.DB.WithContext(ctx).Create(user).Error
+	return user, violation(err)
 }
 
-func (r *UserRepositoryImpl) FindAll() ([]models.User, error) {
+func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.DB.Find(&users).Error
+	err := r.scoped(ctx).Find(&users).Error
 	return users, err
 }
 
-func (r *UserRepositoryImpl) FindById(id string) (*models.User, error) {
+func (r *UserRepositoryImpl) FindById(ctx context.Context, id string, selection *fieldset.Selection) (*models.User, error) {
 	var user models.User
-	err := r.DB.Preload("Posts").First(&user, id).Error
-	return &user, err
+	if err := r.scoped(ctx).Scopes(selection.Scope()).First(&user, "id = ?", id).Error; err != nil {
+		return nil, translate(err, custom_error.ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+func (r *UserRepositoryImpl) Update(ctx context.Context, user *models.User, data map[string]interface{}) error {
+	return updateVersioned(r.scoped(ctx), user, &user.Version, data)
+}
+
+// Delete moves the user and their posts to the trash, if the user is still
+// at version. It returns ErrPreconditionFailed if the user changed since.
+func (r *UserRepositoryImpl) Delete(ctx context.Context, id string, version uint) error {
+	var user models.User
+	if err := r.scoped(ctx).First(&user, "id = ?", id).Error; err != nil {
+		return translate(err, custom_error.ErrUserNotFound)
+	}
+
+	now := time.Now()
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user).Where("version = ?", version).Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return custom_error.ErrPreconditionFailed
+		}
+		return tx.Model(&models.Post{}).Where("user_id = ?", user.ID).Update("deleted_at", now).Error
+	})
 }
 
-func (r *UserRepositoryImpl) Update(user *models.User, data map[string]interface{}) error {
-	return r.DB.Model(user).Updates(data).Error
+// UserQuery lists the fields users can be filtered and sorted by.
+var UserQuery = filter.Resource{
+	Fields: map[string]filter.Field{
+		"id":         {Column: "id", Type: filter.Number, Sortable: true},
+		"name":       {Column: "name", Type: filter.String, Sortable: true},
+		"email":      {Column: "email", Type: filter.String, Sortable: true},
+		"role":       {Column: "role", Type: filter.String},
+		"company_id": {Column: "company_id", Type: filter.Number},
+		"created_at": {Column: "created_at", Type: filter.Time, Sortable: true},
+	},
+	DefaultSort: []pagination.Column{{Name: "id"}},
 }
 
-func (r *UserRepositoryImpl) Delete(id string) error {
-	return r.DB.Delete(&models.User{}, id).Error
+// UserFields lists the fields and associations a user response can be limited to.
+var UserFields = fieldset.Resource{
+	Fields: map[string]fieldset.Field{
+		"id":         {Column: "id", Key: "id"},
+		"name":       {Column: "name", Key: "name"},
+		"email":      {Column: "email", Key: "email"},
+		"role":       {Column: "role", Key: "role"},
+		"company_id": {Column: "company_id", Key: "company_id"},
+		"created_at": {Column: "created_at", Key: "created_at"},
+	},
+	Includes: map[string]fieldset.Include{
+		// Drafts and scheduled posts are only listed through the posts endpoint
+		"posts":   {Association: "Posts", Key: "posts", Conditions: publishedPosts, Permission: enum.PostsRead},
+		"company": {Association: "Company", Key: "company", ForeignKey: "company_id", Permission: enum.CompaniesRead},
+	},
+	// The ETag is built from the version
+	Required: []string{"id", "version"},
 }
 
-func (r *UserRepositoryImpl) Paginate(offset, limit int) ([]models.User, error) {
-	var users []models.User
-	err := r.DB.Limit(limit).Offset(offset).Find(&users).Error
-	return users, err
+// Paginate lists the users matching query a page at a time.
+func (r *UserRepositoryImpl) Paginate(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.User], error) {
+	users := r.scoped(ctx).Scopes(query.Scope)
+	return pagination.Find[models.User](users, params, query.Sort, selection.Scope(columns(query.Sort)...))
+}
+
+// scoped limits queries to the tenant of the request.
+func (r *UserRepositoryImpl) scoped(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Scopes(tenant.Users(ctx))
 }
 
 func (r *UserRepositoryImpl) MultipleUpdateSaveTransaction(user *models.User) (*models.User, error) {
@@ -79,12 +143,12 @@ func (r *UserRepositoryImpl) MultipleUpdateSaveTransaction(user *models.User) (*
 	return &result, nil // Return the updated user if successful
 }
 
-func (r *UserRepositoryImpl) FindByEmail(email string) (*models.User, error) {
+func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.DB.Where("email = ?", email).First(&user).Error
+	err := r.scoped(ctx).Where("email = ?", email).First(&user).Error
 	log.Println("error in repo ", err)
 	if err != nil {
-		return nil, err
+		return nil, translate(err, custom_error.ErrUserNotFound)
 	}
 	return &user, nil
 }
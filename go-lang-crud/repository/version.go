@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"golang-crud/custom_error"
+
+	"gorm.io/gorm"
+)
+
+// updateVersioned applies data to model only if its row is still at the
+// version the model was loaded with, bumping the version. It returns
+// ErrPreconditionFailed if another request updated the row in between.
+func updateVersioned(db *gorm.DB, model interface{}, version *uint, data map[string]interface{}) error {
+	changes := make(map[string]interface{}, len(data)+1)
+	for column, value := range data {
+		changes[column] = value
+	}
+	changes["version"] = gorm.Expr("version + 1")
+
+	result := db.Model(model).Where("version = ?", *version).Updates(changes)
+	if result.Error != nil {
+		return violation(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return custom_error.ErrPreconditionFailed
+	}
+	*version++
+	return nil
+}
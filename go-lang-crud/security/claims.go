@@ -0,0 +1,64 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"golang-crud/enum"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrInvalidClaims is returned when a correctly signed token carries claims
+// we don't accept (wrong issuer or audience, expired, missing fields...).
+var ErrInvalidClaims = errors.New("invalid token claims")
+
+// Claims are the claims carried by every access token.
+type Claims struct {
+	Role      enum.Role `json:"role"`
+	CompanyID uint      `json:"company,omitempty"`
+	SessionID string    `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// TokenOptions configures the issuer and audience stamped into access tokens
+// and how strictly they are checked when tokens come back.
+type TokenOptions struct {
+	Issuer   string
+	Audience []string
+	// Leeway tolerates clock skew between us and other services
+	// when checking exp, iat and nbf.
+	Leeway time.Duration
+}
+
+// Validate checks the claims against the options at the given time.
+func (c *Claims) Validate(options TokenOptions, now time.Time) error {
+	if !c.VerifyExpiresAt(now.Add(-options.Leeway), true) {
+		return fmt.Errorf("%w: token is expired", ErrInvalidClaims)
+	}
+	if !c.VerifyIssuedAt(now.Add(options.Leeway), true) {
+		return fmt.Errorf("%w: token used before issued", ErrInvalidClaims)
+	}
+	if !c.VerifyNotBefore(now.Add(options.Leeway), false) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidClaims)
+	}
+	if options.Issuer != "" && !c.VerifyIssuer(options.Issuer, true) {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidClaims, c.Issuer)
+	}
+	if len(options.Audience) > 0 && !c.hasAudience(options.Audience) {
+		return fmt.Errorf("%w: unexpected audience %v", ErrInvalidClaims, c.Audience)
+	}
+	if c.Subject == "" || c.ID == "" || c.SessionID == "" {
+		return fmt.Errorf("%w: missing sub, jti or sid", ErrInvalidClaims)
+	}
+	return nil
+}
+
+func (c *Claims) hasAudience(accepted []string) bool {
+	for _, audience := range accepted {
+		if c.VerifyAudience(audience, true) {
+			return true
+		}
+	}
+	return false
+}
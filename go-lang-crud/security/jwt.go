@@ -1,22 +1,90 @@
 package security
 
 import (
+	"errors"
 	"golang-crud/models"
 	"log"
-	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func GenerateJWT(user *models.User) (string, error) {
+// AccessTokenTTL is how long an access token stays valid. Clients renew it
+// with their refresh token instead of logging in again.
+var AccessTokenTTL = 15 * time.Minute
+
+// AccessTokens issues and verifies access tokens with the keys of a key
+// manager, stamping and checking the claims set by the options.
+type AccessTokens struct {
+	keys    *KeyManager
+	options TokenOptions
+}
+
+func NewAccessTokens(keys *KeyManager, options TokenOptions) *AccessTokens {
+	return &AccessTokens{keys: keys, options: options}
+}
+
+// Keys returns the key manager tokens are signed with.
+func (t *AccessTokens) Keys() *KeyManager {
+	return t.keys
+}
+
+// Generate issues an access token for the user, bound to the session
+// (refresh-token family) it was issued for.
+func (t *AccessTokens) Generate(user *models.User, sessionID string) (string, error) {
 	log.Println("Generating jwt token")
-	claims := jwt.MapClaims{
-		"sub":  user.ID,
-		"role": user.Role,
-		"exp":  time.Now().Add(time.Hour * 24).Unix(),
+	if t.keys == nil {
+		return "", errors.New("no key manager configured")
+	}
+	tokenID, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		Role:      user.Role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    t.options.Issuer,
+			Audience:  t.options.Audience,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("SECRET")))
+	if user.CompanyID != nil {
+		claims.CompanyID = *user.CompanyID
+	}
+
+	return t.keys.Sign(jwt.NewWithClaims(jwt.SigningMethodNone, claims))
+}
+
+// Parse verifies the token signature against the key named by its kid
+// header and validates its claims. Malformed tokens are reported as errors,
+// never as panics, so callers can simply answer 401.
+func (t *AccessTokens) Parse(tokenString string) (*Claims, error) {
+	if t.keys == nil {
+		return nil, errors.New("no key manager configured")
+	}
+
+	claims := &Claims{}
+	// Claims are validated below so leeway, issuer and audience apply.
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, claims, t.keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if err := claims.Validate(t.options, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
 }
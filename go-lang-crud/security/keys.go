@@ -0,0 +1,289 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeyStatus controls what a signing key may be used for.
+type KeyStatus string
+
+const (
+	// KeyActive keys can sign new tokens and verify existing ones.
+	KeyActive KeyStatus = "active"
+	// KeyRetired keys only verify tokens issued before the last rotation.
+	KeyRetired KeyStatus = "retired"
+)
+
+const minRSAKeyBits = 2048
+
+// SigningKey is a private key identified by the `kid` stamped into tokens.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	Status     KeyStatus
+}
+
+// PublicKey returns the key used to verify tokens signed with this key.
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
+
+// KeyManager holds every key that tokens may be verified with and tracks
+// which one currently signs new tokens.
+type KeyManager struct {
+	mu        sync.RWMutex
+	keys      map[string]*SigningKey
+	currentID string
+}
+
+func NewKeyManager() *KeyManager {
+	return &KeyManager{keys: make(map[string]*SigningKey)}
+}
+
+// Add registers a key. An active key becomes the signing key and demotes
+// the previous signing key to retired.
+func (m *KeyManager) Add(key *SigningKey) error {
+	if key.ID == "" {
+		return errors.New("signing key has no kid")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.keys[key.ID]; exists {
+		return fmt.Errorf("signing key %q already registered", key.ID)
+	}
+	m.keys[key.ID] = key
+
+	if key.Status == KeyActive {
+		if current, ok := m.keys[m.currentID]; ok {
+			current.Status = KeyRetired
+		}
+		m.currentID = key.ID
+	}
+	return nil
+}
+
+// Rotate generates a fresh key, makes it the signing key and retires the
+// previous one. Retired keys keep verifying tokens until they are removed.
+func (m *KeyManager) Rotate(method jwt.SigningMethod) (*SigningKey, error) {
+	key, err := GenerateSigningKey(method)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Add(key); err != nil {
+		return nil, err
+	}
+	log.Println("Rotated jwt signing key, new kid ", key.ID)
+	return key, nil
+}
+
+// Remove stops trusting a retired key.
+func (m *KeyManager) Remove(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if kid == m.currentID {
+		return fmt.Errorf("signing key %q is in use and cannot be removed", kid)
+	}
+	delete(m.keys, kid)
+	return nil
+}
+
+// Current returns the key new tokens are signed with.
+func (m *KeyManager) Current() (*SigningKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[m.currentID]
+	if !ok {
+		return nil, errors.New("no active signing key")
+	}
+	return key, nil
+}
+
+// Lookup returns the key with the given kid, whether active or retired.
+func (m *KeyManager) Lookup(kid string) (*SigningKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[kid]
+	return key, ok
+}
+
+// Keys returns every registered key ordered by kid.
+func (m *KeyManager) Keys() []*SigningKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// Sign signs the token with the current key and stamps its kid.
+func (m *KeyManager) Sign(token *jwt.Token) (string, error) {
+	key, err := m.Current()
+	if err != nil {
+		return "", err
+	}
+	token.Method = key.Method
+	token.Header["alg"] = key.Method.Alg()
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc resolves the verification key from the token's kid header and
+// rejects tokens whose algorithm doesn't match that key.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	key, ok := m.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey(), nil
+}
+
+// GenerateSigningKey creates a new active key for RS256 or EdDSA.
+func GenerateSigningKey(method jwt.SigningMethod) (*SigningKey, error) {
+	kid, err := randomString(12)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch method {
+	case jwt.SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	case jwt.SigningMethodEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing method: %s", method.Alg())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: kid, Method: method, PrivateKey: private, Status: KeyActive}, nil
+}
+
+// SigningMethodByName maps the JWT_SIGNING_ALG setting to a signing method.
+func SigningMethodByName(name string) (jwt.SigningMethod, error) {
+	switch strings.ToUpper(name) {
+	case "", "RS256":
+		return jwt.SigningMethodRS256, nil
+	case "EDDSA":
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm: %s", name)
+}
+
+// LoadKeyManager reads every `<kid>.pem` private key in dir. The key named
+// activeKID signs new tokens; all others are retired and only verify. When
+// activeKID is empty the last key in lexical order is used, so rotating is a
+// matter of dropping a newer file into the directory.
+func LoadKeyManager(dir, activeKID string) (*KeyManager, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+	sort.Strings(files)
+
+	if activeKID == "" {
+		activeKID = strings.TrimSuffix(filepath.Base(files[len(files)-1]), ".pem")
+	}
+
+	manager := NewKeyManager()
+	var active *SigningKey
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := readSigningKey(file, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if kid == activeKID {
+			active = key
+			continue
+		}
+		key.Status = KeyRetired
+		if err := manager.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if active == nil {
+		return nil, fmt.Errorf("active signing key %q not found in %s", activeKID, dir)
+	}
+	active.Status = KeyActive
+	if err := manager.Add(active); err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+func readSigningKey(path, kid string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("%s: RSA keys must be at least %d bits", path, minRSAKeyBits)
+		}
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: private}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: private}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported key type %T", path, parsed)
+}
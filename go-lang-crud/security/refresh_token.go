@@ -0,0 +1,47 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for a new pair.
+var RefreshTokenTTL = 7 * 24 * time.Hour
+
+// GenerateRefreshToken returns a new opaque refresh token and the hash that
+// should be persisted. The raw token is only ever handed to the client.
+func GenerateRefreshToken() (string, string, error) {
+	raw, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	return raw, HashRefreshToken(raw), nil
+}
+
+// GenerateSessionID returns a random identifier for a refresh-token family.
+func GenerateSessionID() (string, error) {
+	return randomString(16)
+}
+
+// GenerateRandomSecret returns a random string suitable as an unguessable
+// placeholder password.
+func GenerateRandomSecret() (string, error) {
+	return randomString(32)
+}
+
+// HashRefreshToken hashes a raw refresh token for storage and lookup.
+func HashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
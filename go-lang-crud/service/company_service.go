@@ -1,10 +1,21 @@
 package service
 
-import "golang-crud/models"
+import (
+	"context"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
+	"golang-crud/models"
+	"golang-crud/pagination"
+)
 
 // CompanyService defines the behavior expected for the company-related operations
 type CompanyService interface {
-	CreateCompany(company *models.Company) error
-	GetAllCompanies() ([]models.Company, error)
-	DeleteCompany(id string) error
+	CreateCompany(ctx context.Context, company *models.Company) error
+	GetAllCompanies(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.Company], error)
+	GetCompanyById(ctx context.Context, id string, selection *fieldset.Selection) (*models.Company, error)
+	UpdateCompany(ctx context.Context, id string, version uint, data map[string]interface{}) (*models.Company, error)
+	DeleteCompany(ctx context.Context, id string, version uint) error
+	GetCompanyUsers(ctx context.Context, id string) ([]models.User, error)
+	AddUserToCompany(ctx context.Context, id string, userId string) error
+	RemoveUserFromCompany(ctx context.Context, id string, userId string) error
 }
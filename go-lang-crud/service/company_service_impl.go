@@ -1,7 +1,12 @@
 package service
 
 import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
 	"golang-crud/repository"
 )
 
@@ -16,16 +21,55 @@ func NewCompanyServiceImpl(repo *repository.CompanyRepository) *CompanyServiceIm
 }
 
 // CreateCompany creates a new company
-func (s *CompanyServiceImpl) CreateCompany(company *models.Company) error {
-	return s.repo.Create(company)
+func (s *CompanyServiceImpl) CreateCompany(ctx context.Context, company *models.Company) error {
+	return s.repo.Create(ctx, company)
 }
 
-// GetAllCompanies returns a list of all companies
-func (s *CompanyServiceImpl) GetAllCompanies() ([]models.Company, error) {
-	return s.repo.FindAll()
+// GetAllCompanies returns a page of companies
+func (s *CompanyServiceImpl) GetAllCompanies(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.Company], error) {
+	return s.repo.FindAll(ctx, query, selection, params)
+}
+
+// GetCompanyById returns a company by ID
+func (s *CompanyServiceImpl) GetCompanyById(ctx context.Context, id string, selection *fieldset.Selection) (*models.Company, error) {
+	return s.repo.FindById(ctx, id, selection)
+}
+
+// UpdateCompany applies the changed fields to a company and returns it. It
+// fails with ErrPreconditionFailed unless the company is still at version.
+func (s *CompanyServiceImpl) UpdateCompany(ctx context.Context, id string, version uint, data map[string]interface{}) (*models.Company, error) {
+	company, err := s.repo.FindById(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if company.Version != version {
+		return nil, custom_error.ErrPreconditionFailed
+	}
+	if len(data) == 0 {
+		return company, nil
+	}
+	if err := s.repo.Update(ctx, company, data); err != nil {
+		return nil, err
+	}
+	return company, nil
 }
 
 // DeleteCompany deletes a company by ID
-func (s *CompanyServiceImpl) DeleteCompany(id string) error {
-	return s.repo.DeleteById(id)
+func (s *CompanyServiceImpl) DeleteCompany(ctx context.Context, id string, version uint) error {
+	return s.repo.DeleteById(ctx, id, version)
+}
+
+// GetCompanyUsers lists the users belonging to a company
+func (s *CompanyServiceImpl) GetCompanyUsers(ctx context.Context, id string) ([]models.User, error) {
+	return s.repo.FindUsers(ctx, id)
+}
+
+// AddUserToCompany makes a user a member of the company
+func (s *CompanyServiceImpl) AddUserToCompany(ctx context.Context, id string, userId string) error {
+	return s.repo.AssignUser(ctx, id, userId)
+}
+
+// RemoveUserFromCompany takes a user out of the company
+func (s *CompanyServiceImpl) RemoveUserFromCompany(ctx context.Context, id string, userId string) error {
+	return s.repo.RemoveUser(ctx, id, userId)
 }
@@ -0,0 +1,184 @@
+// service/identity_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/repository"
+	"golang-crud/security"
+	"golang-crud/tenant"
+	"log"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ExternalAccount is the identity an identity provider vouched for.
+type ExternalAccount struct {
+	Provider string
+	Subject  string
+	Email    string
+	// EmailVerified is set when the provider asserts the user owns Email
+	EmailVerified bool
+	Name          string
+}
+
+// ProvisioningOptions controls just-in-time creation of users on their
+// first social login.
+type ProvisioningOptions struct {
+	Enabled     bool
+	DefaultRole enum.Role
+	// DomainCompanies maps email domains to the company new users join.
+	DomainCompanies map[string]uint
+	// DefaultCompanyID is used when the email domain isn't mapped; zero
+	// refuses to provision users from unmapped domains.
+	DefaultCompanyID uint
+	// TrustedProviders are the providers whose verified emails are enough
+	// to link an existing user or provision a new one.
+	TrustedProviders []string
+}
+
+type IdentityService struct {
+	repo     *repository.ExternalIdentityRepository
+	userRepo repository.UserRepository
+	options  ProvisioningOptions
+}
+
+func NewIdentityService(repo *repository.ExternalIdentityRepository, userRepo repository.UserRepository, options ProvisioningOptions) *IdentityService {
+	return &IdentityService{repo: repo, userRepo: userRepo, options: options}
+}
+
+// ResolveUser returns the user an external account signs in as. Accounts
+// already linked resolve directly. Otherwise, if a trusted provider verified
+// the email, the account is linked to the user with the same email, or a new
+// user is provisioned when enabled. Any other account is refused with
+// ErrProvisioningDenied: anyone can show a victim's email at a provider that
+// doesn't verify it, so it has to be linked with LinkAccount instead.
+func (s *IdentityService) ResolveUser(ctx context.Context, account ExternalAccount) (*models.User, error) {
+	if account.Provider == "" || account.Subject == "" {
+		return nil, errors.New("external account has no provider or subject")
+	}
+	// The account decides who signs in, whichever tenant they belong to
+	ctx = tenant.System(ctx)
+
+	identity, err := s.repo.FindByProviderSubject(account.Provider, account.Subject)
+	if err == nil {
+		return s.userRepo.FindById(ctx, strconv.FormatUint(uint64(identity.UserID), 10), nil)
+	}
+	if !errors.Is(err, custom_error.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	if !s.trustsEmail(account) {
+		return nil, fmt.Errorf("%s account %s has no verified email from a trusted provider: %w", account.Provider, account.Subject, custom_error.ErrProvisioningDenied)
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, account.Email)
+	if err != nil {
+		if !errors.Is(err, custom_error.ErrUserNotFound) {
+			return nil, err
+		}
+		if !s.options.Enabled {
+			return nil, fmt.Errorf("user with Email %s not found: %w", account.Email, custom_error.ErrUserNotFound)
+		}
+		if user, err = s.provision(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.Link(user, account); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// LinkAccount links the external account to a signed-in user, whatever its
+// email, since the user proved they hold both. The user is looked up across
+// tenants: the callback completing the link carries no access token.
+func (s *IdentityService) LinkAccount(ctx context.Context, userID uint, account ExternalAccount) (*models.User, error) {
+	if account.Provider == "" || account.Subject == "" {
+		return nil, errors.New("external account has no provider or subject")
+	}
+	user, err := s.userRepo.FindById(tenant.System(ctx), strconv.FormatUint(uint64(userID), 10), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Link(user, account); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Link records that the user can sign in with the external account.
+func (s *IdentityService) Link(user *models.User, account ExternalAccount) error {
+	log.Println("Linking ", account.Provider, " account to user ", user.ID)
+	return s.repo.Create(&models.ExternalIdentity{
+		UserID:   user.ID,
+		Provider: account.Provider,
+		Subject:  account.Subject,
+		Email:    account.Email,
+	})
+}
+
+// GetIdentities lists the external accounts linked to a user.
+func (s *IdentityService) GetIdentities(userId uint) ([]models.ExternalIdentity, error) {
+	return s.repo.FindByUserId(userId)
+}
+
+func (s *IdentityService) provision(ctx context.Context, account ExternalAccount) (*models.User, error) {
+	companyID := s.companyFor(account.Email)
+	if companyID == 0 {
+		return nil, fmt.Errorf("no company mapped for %s: %w", account.Email, custom_error.ErrProvisioningDenied)
+	}
+
+	// Provisioned users sign in through their provider; the random password
+	// only satisfies the schema and is never handed out.
+	password, err := security.GenerateRandomSecret()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	name := account.Name
+	if name == "" {
+		name = strings.Split(account.Email, "@")[0]
+	}
+
+	log.Println("Provisioning user for ", account.Provider, " account ", account.Email)
+	return s.userRepo.Create(ctx, &models.User{
+		Name:      name,
+		Email:     account.Email,
+		Password:  string(hashedPassword),
+		Role:      s.options.DefaultRole,
+		CompanyID: &companyID,
+	})
+}
+
+// trustsEmail reports whether the account's email proves who the user is.
+func (s *IdentityService) trustsEmail(account ExternalAccount) bool {
+	if account.Email == "" || !account.EmailVerified {
+		return false
+	}
+	for _, provider := range s.options.TrustedProviders {
+		if strings.EqualFold(provider, account.Provider) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *IdentityService) companyFor(email string) uint {
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if companyID, ok := s.options.DomainCompanies[domain]; ok {
+		return companyID
+	}
+	return s.options.DefaultCompanyID
+}
@@ -1,7 +1,11 @@
 package service
 
 import (
+	"context"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -11,32 +15,32 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) CreateUser(user *models.User) (*models.User, error) {
-	args := m.Called(user)
+func (m *MockUserService) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) GetAllUsers() ([]models.User, error) {
-	args := m.Called()
+func (m *MockUserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
-func (m *MockUserService) GetUserById(id string) (*models.User, error) {
-	args := m.Called(id)
+func (m *MockUserService) GetUserById(ctx context.Context, id string, selection *fieldset.Selection) (*models.User, error) {
+	args := m.Called(ctx, id, selection)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) UpdateUserDetails(user *models.User, data map[string]interface{}) error {
-	args := m.Called(user, data)
+func (m *MockUserService) UpdateUserDetails(ctx context.Context, user *models.User, data map[string]interface{}) error {
+	args := m.Called(ctx, user, data)
 	return args.Error(0)
 }
 
-func (m *MockUserService) DeleteUser(id string) error {
-	args := m.Called(id)
+func (m *MockUserService) DeleteUser(ctx context.Context, id string, version uint) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func (m *MockUserService) PaginateUsers(page, pageSize int) ([]models.User, error) {
-	args := m.Called(page, pageSize)
-	return args.Get(0).([]models.User), args.Error(1)
+func (m *MockUserService) PaginateUsers(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.User], error) {
+	args := m.Called(ctx, query, selection, params)
+	return args.Get(0).(*pagination.Page[models.User]), args.Error(1)
 }
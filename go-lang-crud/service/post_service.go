@@ -2,8 +2,15 @@
 package service
 
 import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
 	"golang-crud/repository"
+	"time"
 )
 
 type PostService struct {
@@ -14,14 +21,49 @@ func NewPostService(repo *repository.PostRepository) *PostService {
 	return &PostService{repo: repo}
 }
 
-func (s *PostService) CreatePost(post *models.Post) error {
-	return s.repo.Create(post)
+// CreatePost saves a new post, as a draft unless another status is given.
+func (s *PostService) CreatePost(ctx context.Context, post *models.Post) error {
+	if post.Status == "" {
+		post.Status = enum.Draft
+	}
+	if !post.Status.IsValid() {
+		return custom_error.ErrInvalidPostStatus
+	}
+	if post.Status == enum.Published && post.PublishedAt == nil {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+	return s.repo.Create(ctx, post)
 }
 
-func (s *PostService) GetPostsByUserId(userId string) ([]models.Post, error) {
-	return s.repo.FindByUserId(userId)
+// GetPostsByUserId lists a user's posts; drafts, archived and scheduled posts
+// are only included when includeUnpublished is set.
+func (s *PostService) GetPostsByUserId(ctx context.Context, userId string, includeUnpublished bool, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.Post], error) {
+	return s.repo.FindByUserId(ctx, userId, includeUnpublished, query, selection, params)
 }
 
-func (s *PostService) GetPostById(id string) (*models.Post, error) {
-	return s.repo.FindById(id)
+func (s *PostService) GetPostById(ctx context.Context, id string, selection *fieldset.Selection) (*models.Post, error) {
+	return s.repo.FindById(ctx, id, selection)
+}
+
+// UpdatePost applies the changed fields to a post. Publishing a post without
+// a publish time makes it visible immediately.
+func (s *PostService) UpdatePost(ctx context.Context, post *models.Post, data map[string]interface{}) error {
+	if status, ok := data["status"].(enum.PostStatus); ok {
+		if !status.IsValid() {
+			return custom_error.ErrInvalidPostStatus
+		}
+		_, scheduled := data["published_at"]
+		if status == enum.Published && post.PublishedAt == nil && !scheduled {
+			data["published_at"] = time.Now()
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return s.repo.Update(ctx, post, data)
+}
+
+func (s *PostService) DeletePost(ctx context.Context, post *models.Post) error {
+	return s.repo.Delete(ctx, post)
 }
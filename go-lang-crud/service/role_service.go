@@ -0,0 +1,96 @@
+// service/role_service.go
+package service
+
+import (
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/repository"
+)
+
+type RoleService struct {
+	repo *repository.RoleRepository
+}
+
+func NewRoleService(repo *repository.RoleRepository) *RoleService {
+	return &RoleService{repo: repo}
+}
+
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	return s.repo.FindAll()
+}
+
+func (s *RoleService) GetRole(name enum.Role) (*models.Role, error) {
+	role, err := s.repo.FindByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("role %s: %w", name, err)
+	}
+	return role, nil
+}
+
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	return s.repo.FindAllPermissions()
+}
+
+func (s *RoleService) CreateRole(name enum.Role, description string, permissionNames []enum.Permission) (*models.Role, error) {
+	permissions, err := s.lookupPermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{Name: name, Description: description, Permissions: permissions}
+	if err := s.repo.Create(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole replaces the description and permission set of a role.
+func (s *RoleService) UpdateRole(name enum.Role, description string, permissionNames []enum.Permission) (*models.Role, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.lookupPermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Description = description
+	if err := s.repo.Update(role, permissions); err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+	return role, nil
+}
+
+// DeleteRole removes a role no user is assigned to anymore.
+func (s *RoleService) DeleteRole(name enum.Role) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountUsers(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("role %s has %d users: %w", name, count, custom_error.ErrRoleInUse)
+	}
+	return s.repo.Delete(role)
+}
+
+func (s *RoleService) lookupPermissions(names []enum.Permission) ([]models.Permission, error) {
+	for _, name := range names {
+		if !name.IsValid() {
+			return nil, fmt.Errorf("%w: %s", custom_error.ErrUnknownPermission, name)
+		}
+	}
+	if len(names) == 0 {
+		return []models.Permission{}, nil
+	}
+	return s.repo.FindPermissionsByName(names)
+}
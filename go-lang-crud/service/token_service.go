@@ -0,0 +1,123 @@
+// service/token_service.go
+package service
+
+import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/models"
+	"golang-crud/repository"
+	"golang-crud/security"
+	"golang-crud/tenant"
+	"log"
+	"strconv"
+	"time"
+)
+
+// TokenPair is returned to clients on login and on every refresh.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type TokenService struct {
+	repo         *repository.RefreshTokenRepository
+	userRepo     repository.UserRepository
+	accessTokens *security.AccessTokens
+}
+
+func NewTokenService(repo *repository.RefreshTokenRepository, userRepo repository.UserRepository, accessTokens *security.AccessTokens) *TokenService {
+	return &TokenService{repo: repo, userRepo: userRepo, accessTokens: accessTokens}
+}
+
+// IssueTokens starts a new session for the user.
+func (s *TokenService) IssueTokens(user *models.User) (*TokenPair, error) {
+	familyID, err := security.GenerateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, pair, err := s.newPair(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(refreshToken); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new pair in the same session.
+// Presenting a token that was already rotated revokes the whole session,
+// since either the client or an attacker is holding a stolen copy.
+func (s *TokenService) RefreshTokens(ctx context.Context, raw string) (*TokenPair, error) {
+	current, err := s.repo.FindByHash(security.HashRefreshToken(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		log.Println("Refresh token reuse detected for session ", current.FamilyID)
+		if err := s.repo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, custom_error.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, custom_error.ErrInvalidRefreshToken
+	}
+
+	// The refresh token, not a tenant, decides whose tokens these are
+	user, err := s.userRepo.FindById(tenant.System(ctx), strconv.FormatUint(uint64(current.UserID), 10), nil)
+	if err != nil {
+		return nil, custom_error.ErrInvalidRefreshToken
+	}
+
+	next, pair, err := s.newPair(user, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Rotate(current, next); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// RevokeTokens ends the session the refresh token belongs to.
+func (s *TokenService) RevokeTokens(raw string) error {
+	current, err := s.repo.FindByHash(security.HashRefreshToken(raw))
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeFamily(current.FamilyID)
+}
+
+func (s *TokenService) newPair(user *models.User, familyID string) (*models.RefreshToken, *TokenPair, error) {
+	accessToken, err := s.accessTokens.Generate(user, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, hash, err := security.GenerateRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(security.RefreshTokenTTL),
+	}
+
+	return refreshToken, &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: raw,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(security.AccessTokenTTL.Seconds()),
+	}, nil
+}
@@ -0,0 +1,60 @@
+// service/trash_service.go
+package service
+
+import (
+	"context"
+	"golang-crud/repository"
+	"time"
+)
+
+// TrashResource names a kind of record that can be listed in and restored from the trash.
+type TrashResource string
+
+const (
+	TrashUsers     TrashResource = "users"
+	TrashPosts     TrashResource = "posts"
+	TrashCompanies TrashResource = "companies"
+)
+
+type TrashService struct {
+	repo *repository.TrashRepository
+}
+
+func NewTrashService(repo *repository.TrashRepository) *TrashService {
+	return &TrashService{repo: repo}
+}
+
+// GetDeleted lists the trashed records of a resource, newest first. The
+// second result is false for an unknown resource.
+func (s *TrashService) GetDeleted(ctx context.Context, resource TrashResource) (interface{}, bool, error) {
+	switch resource {
+	case TrashUsers:
+		users, err := s.repo.FindDeletedUsers(ctx)
+		return users, true, err
+	case TrashPosts:
+		posts, err := s.repo.FindDeletedPosts(ctx)
+		return posts, true, err
+	case TrashCompanies:
+		companies, err := s.repo.FindDeletedCompanies(ctx)
+		return companies, true, err
+	}
+	return nil, false, nil
+}
+
+// Restore brings a record back together with the children deleted along with it.
+func (s *TrashService) Restore(ctx context.Context, resource TrashResource, id string) (bool, error) {
+	switch resource {
+	case TrashUsers:
+		return true, s.repo.RestoreUser(ctx, id)
+	case TrashPosts:
+		return true, s.repo.RestorePost(ctx, id)
+	case TrashCompanies:
+		return true, s.repo.RestoreCompany(ctx, id)
+	}
+	return false, nil
+}
+
+// Purge permanently deletes records that have been in the trash longer than retention.
+func (s *TrashService) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.Purge(ctx, time.Now().Add(-retention))
+}
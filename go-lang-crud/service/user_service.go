@@ -1,15 +1,21 @@
 // service/user_service.go
 package service
 
-import "golang-crud/models"
+import (
+	"context"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
+	"golang-crud/models"
+	"golang-crud/pagination"
+)
 
 type UserService interface {
-	CreateUser(user *models.User) (*models.User, error)
-	GetAllUsers() ([]models.User, error)
-	GetUserById(id string) (*models.User, error)
-	UpdateUserDetails(user *models.User, data map[string]interface{}) error
-	DeleteUser(id string) error
-	PaginateUsers(page, pageSize int) ([]models.User, error)
-	AuthenticateUser(email, password string) (string, error)
-	GetUserByEmail(email string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	GetUserById(ctx context.Context, id string, selection *fieldset.Selection) (*models.User, error)
+	UpdateUserDetails(ctx context.Context, user *models.User, data map[string]interface{}) error
+	DeleteUser(ctx context.Context, id string, version uint) error
+	PaginateUsers(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.User], error)
+	AuthenticateUser(ctx context.Context, email, password string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 }
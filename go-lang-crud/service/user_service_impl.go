@@ -1,12 +1,16 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"golang-crud/custom_error"
+	"golang-crud/fieldset"
+	"golang-crud/filter"
 	"golang-crud/models"
+	"golang-crud/pagination"
 	"golang-crud/repository"
-	"golang-crud/security"
+	"golang-crud/tenant"
 	"log"
 
 	"golang.org/x/crypto/bcrypt"
@@ -20,7 +24,7 @@ func NewUserServiceImpl(repo repository.UserRepository) UserService {
 	return &UserServiceImpl{repo: repo}
 }
 
-func (s *UserServiceImpl) CreateUser(user *models.User) (*models.User, error) {
+func (s *UserServiceImpl) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -28,23 +32,28 @@ func (s *UserServiceImpl) CreateUser(user *models.User) (*models.User, error) {
 
 	// Set the hashed password back to the user model
 	user.Password = string(hashedPassword)
-	result, err := s.repo.Create(user)
+
+	// Users created inside a tenant always join that tenant's company
+	if scope, ok := tenant.FromContext(ctx); ok && !scope.AllTenants {
+		user.CompanyID = &scope.CompanyID
+	}
+	result, err := s.repo.Create(ctx, user)
 	if err != nil {
 		return nil, err // Ensure this line exists
 	}
 	return result, nil
 }
 
-func (s *UserServiceImpl) GetAllUsers() ([]models.User, error) {
-	users, err := s.repo.FindAll()
+func (s *UserServiceImpl) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	users, err := s.repo.FindAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve all users: %w", err)
 	}
 	return users, nil
 }
 
-func (s *UserServiceImpl) GetUserById(id string) (*models.User, error) {
-	user, err := s.repo.FindById(id)
+func (s *UserServiceImpl) GetUserById(ctx context.Context, id string, selection *fieldset.Selection) (*models.User, error) {
+	user, err := s.repo.FindById(ctx, id, selection)
 	if err != nil {
 		if errors.Is(err, custom_error.ErrUserNotFound) {
 			return nil, fmt.Errorf("user with ID %s not found: %w", id, err)
@@ -54,7 +63,7 @@ func (s *UserServiceImpl) GetUserById(id string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *UserServiceImpl) UpdateUserDetails(user *models.User, data map[string]interface{}) error {
+func (s *UserServiceImpl) UpdateUserDetails(ctx context.Context, user *models.User, data map[string]interface{}) error {
 	if password, ok := data["password"]; ok {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password.(string)), bcrypt.DefaultCost)
 		if err != nil {
@@ -64,7 +73,7 @@ func (s *UserServiceImpl) UpdateUserDetails(user *models.User, data map[string]i
 		data["password"] = string(hashedPassword)
 	}
 
-	err := s.repo.Update(user, data)
+	err := s.repo.Update(ctx, user, data)
 	if err != nil {
 		if errors.Is(err, custom_error.ErrUserNotFound) {
 			return fmt.Errorf("user with ID %v not found: %w", user.ID, err)
@@ -74,8 +83,8 @@ func (s *UserServiceImpl) UpdateUserDetails(user *models.User, data map[string]i
 	return nil
 }
 
-func (s *UserServiceImpl) DeleteUser(id string) error {
-	err := s.repo.Delete(id)
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, id string, version uint) error {
+	err := s.repo.Delete(ctx, id, version)
 	if err != nil {
 		if errors.Is(err, custom_error.ErrUserNotFound) {
 			return fmt.Errorf("user with ID %s not found: %w", id, err)
@@ -85,44 +94,33 @@ func (s *UserServiceImpl) DeleteUser(id string) error {
 	return nil
 }
 
-func (s *UserServiceImpl) PaginateUsers(page, pageSize int) ([]models.User, error) {
-	if page <= 0 || pageSize <= 0 {
-		return nil, errors.New("invalid page or page size")
-	}
-
-	offset := (page - 1) * pageSize
-	users, err := s.repo.Paginate(offset, pageSize)
+func (s *UserServiceImpl) PaginateUsers(ctx context.Context, query *filter.Query, selection *fieldset.Selection, params pagination.Params) (*pagination.Page[models.User], error) {
+	page, err := s.repo.Paginate(ctx, query, selection, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to paginate users: %w", err)
 	}
-	return users, nil
+	return page, nil
 }
 
-func (s *UserServiceImpl) AuthenticateUser(email, password string) (string, error) {
-	// Fetch the user by email
-	user, err := s.repo.FindByEmail(email)
+func (s *UserServiceImpl) AuthenticateUser(ctx context.Context, email, password string) (*models.User, error) {
+	// Fetch the user by email, whichever tenant they belong to
+	user, err := s.repo.FindByEmail(tenant.System(ctx), email)
 	if err != nil {
 		log.Println("Error when fetching user ", err)
-		return "", errors.New("invalid email or password")
+		return nil, errors.New("invalid email or password")
 	}
 	log.Println("User ", user)
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		log.Println("Error when matching passwrod ", err)
-		return "", errors.New("invalid password")
+		return nil, errors.New("invalid password")
 	}
 
-	token, err := security.GenerateJWT(user)
-	log.Println("generated token ", token, err)
-	if err != nil {
-		return "", err
-	}
-
-	return token, nil
+	return user, nil
 }
 
-func (s *UserServiceImpl) GetUserByEmail(email string) (*models.User, error) {
-	user, err := s.repo.FindByEmail(email)
+func (s *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.repo.FindByEmail(ctx, email)
 	log.Println("user error in service ", err, email)
 	log.Println("user  in service ", user)
 	if err != nil {
@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scope is the slice of data a request may see: the authenticated user's
+// company, everything for super admins, or the published posts of every
+// company for anonymous readers.
+type Scope struct {
+	CompanyID uint
+	// AllTenants lifts the company restriction (tenants:all permission).
+	AllTenants bool
+	// Public limits anonymous requests to published posts.
+	Public bool
+}
+
+// ErrNoScope fails queries made with a context that carries no tenant scope,
+// so a forgotten scope can't expose every tenant's data.
+var ErrNoScope = errors.New("tenant: query without a tenant scope")
+
+// ErrNotPublic fails queries of anonymous requests for data that is never
+// public, such as users and companies.
+var ErrNotPublic = errors.New("tenant: anonymous query of private data")
+
+type contextKey struct{}
+
+// WithScope returns a context carrying the tenant scope.
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope)
+}
+
+// System marks the context of a system operation that isn't bound to a
+// tenant, such as authenticating a user, refreshing tokens or purging the
+// trash, letting it see every tenant.
+func System(ctx context.Context) context.Context {
+	return WithScope(ctx, Scope{AllTenants: true})
+}
+
+// Public marks the context of an anonymous request, which sees the
+// published posts of every tenant and nothing else.
+func Public(ctx context.Context) context.Context {
+	return WithScope(ctx, Scope{Public: true})
+}
+
+// FromContext returns the tenant scope of the request, or of a System
+// operation.
+func FromContext(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(contextKey{}).(Scope)
+	return scope, ok
+}
+
+// limit applies where unless the context may see every tenant, and public
+// to anonymous requests. Queries without a scope fail with ErrNoScope, and
+// anonymous ones with ErrNotPublic when public is nil.
+func limit(ctx context.Context, db *gorm.DB, where func(companyID uint) *gorm.DB, public func() *gorm.DB) *gorm.DB {
+	scope, ok := FromContext(ctx)
+	if !ok {
+		db.AddError(ErrNoScope)
+		return db
+	}
+	if scope.Public {
+		if public == nil {
+			db.AddError(ErrNotPublic)
+			return db
+		}
+		return public()
+	}
+	if scope.AllTenants {
+		return db
+	}
+	return where(scope.CompanyID)
+}
+
+// Users limits a users query to the tenant's company.
+func Users(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return limit(ctx, db, func(companyID uint) *gorm.DB {
+			return db.Where("users.company_id = ?", companyID)
+		}, nil)
+	}
+}
+
+// Posts limits a posts query to posts written by the tenant's users,
+// including users in the trash, or to published posts for anonymous requests.
+func Posts(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return limit(ctx, db, func(companyID uint) *gorm.DB {
+			users := db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.User{}).Select("id").Where("company_id = ?", companyID)
+			return db.Where("posts.user_id IN (?)", users)
+		}, func() *gorm.DB {
+			return db.Where("posts.status = ? AND (posts.published_at IS NULL OR posts.published_at <= ?)", enum.Published, time.Now())
+		})
+	}
+}
+
+// Companies limits a companies query to the tenant's own company.
+func Companies(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return limit(ctx, db, func(companyID uint) *gorm.DB {
+			return db.Where("companies.id = ?", companyID)
+		}, nil)
+	}
+}
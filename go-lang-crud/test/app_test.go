@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-crud/app"
+	"golang-crud/config"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/oauth"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth/providers/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppServesWithoutPostgres(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.Admin}, "secret-password")
+
+	tokens := login(t, handler, "ada@example.com", "secret-password")
+
+	w := serve(handler, http.MethodGet, fmt.Sprintf("/user/%d", user.ID), tokens["access_token"].(string), nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "ada@example.com", decode(t, w)["user"].(map[string]interface{})["email"])
+
+	w = serve(handler, http.MethodGet, fmt.Sprintf("/user/%d", user.ID), "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAppsKeepTheirOwnProviders(t *testing.T) {
+	first := oauth.NewRegistry("first-secret")
+	provider := github.New("client", "secret", "http://localhost/auth/github/callback")
+	first.Add(provider)
+
+	firstApp := newApp(t, newDB(t), app.WithProviders(first))
+	secondApp := newApp(t, newDB(t), app.WithProviders(oauth.NewRegistry("second-secret")))
+
+	w := serve(firstApp.Handler(), http.MethodGet, "/auth/github/login", "", nil)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Location"), "github.com/login/oauth/authorize")
+
+	w = serve(secondApp.Handler(), http.MethodGet, "/auth/github/login", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAppUsesInjectedRepositories(t *testing.T) {
+	db := newDB(t)
+	users := &countingUsers{UserRepository: newUserRepository(db)}
+	handler := newApp(t, db, app.WithRepositories(app.Repositories{Users: users})).Handler()
+	createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.Admin}, "secret-password")
+
+	login(t, handler, "ada@example.com", "secret-password")
+	assert.Equal(t, 1, users.byEmail)
+}
+
+func TestAppRefusesAnUnmigratedDatabase(t *testing.T) {
+	cfg := config.Defaults(config.Prod)
+	_, err := app.New(&cfg, app.WithDB(newEmptyDB(t)), app.WithoutMigrations())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "seed roles")
+}
+
+func TestShutdownRunsEveryHookInReverse(t *testing.T) {
+	application := newApp(t, newDB(t))
+	var stopped []string
+	for _, name := range []string{"database", "cache", "jobs"} {
+		application.OnShutdown(name, func(context.Context) error {
+			stopped = append(stopped, name)
+			if name == "cache" {
+				return errors.New("connection reset")
+			}
+			return nil
+		})
+	}
+
+	err := application.Shutdown(context.Background())
+	assert.Equal(t, []string{"jobs", "cache", "database"}, stopped)
+	assert.EqualError(t, err, "stop cache: connection reset")
+
+	stopped = nil
+	require.NoError(t, application.Shutdown(context.Background()))
+	assert.Empty(t, stopped, "hooks run once")
+}
+
+func TestRunRespectsTheShutdownDeadline(t *testing.T) {
+	cfg := config.Defaults(config.Test)
+	cfg.HTTP.Addr = "127.0.0.1:0"
+	cfg.HTTP.ShutdownTimeout = 50 * time.Millisecond
+	application := newAppWithConfig(t, &cfg, newDB(t))
+	var stopped []string
+	application.OnShutdown("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped = append(stopped, "stuck")
+		return ctx.Err()
+	})
+	application.OnShutdown("quick", func(context.Context) error {
+		stopped = append(stopped, "quick")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := application.Run(ctx)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stop stuck")
+	assert.Equal(t, []string{"quick", "stuck"}, stopped)
+}
+
+func TestRunFailsOnABusyAddress(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	cfg := config.Defaults(config.Test)
+	cfg.HTTP.Addr = busy.Addr().String()
+	application := newAppWithConfig(t, &cfg, newDB(t))
+	stopped := false
+	application.OnShutdown("database", func(context.Context) error {
+		stopped = true
+		return nil
+	})
+
+	err = application.Run(context.Background())
+	assert.ErrorContains(t, err, "listen")
+	assert.True(t, stopped, "what was started is stopped")
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"golang-crud/security"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimsValidate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	leeway := 30 * time.Second
+	options := security.TokenOptions{Issuer: "golang-crud", Audience: []string{"golang-crud", "reports"}, Leeway: leeway}
+	at := func(offset time.Duration) *jwt.NumericDate {
+		return jwt.NewNumericDate(now.Add(offset))
+	}
+	valid := func() security.Claims {
+		return security.Claims{
+			SessionID: "session",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "1",
+				ID:        "token",
+				Issuer:    "golang-crud",
+				Audience:  jwt.ClaimStrings{"golang-crud"},
+				IssuedAt:  at(-time.Minute),
+				NotBefore: at(-time.Minute),
+				ExpiresAt: at(time.Minute),
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*security.Claims)
+		valid  bool
+	}{
+		{"valid", func(*security.Claims) {}, true},
+		{"expired", func(c *security.Claims) { c.ExpiresAt = at(-time.Minute) }, false},
+		{"expired within leeway", func(c *security.Claims) { c.ExpiresAt = at(-leeway + time.Second) }, true},
+		{"expired exactly at leeway", func(c *security.Claims) { c.ExpiresAt = at(-leeway) }, false},
+		{"no expiry", func(c *security.Claims) { c.ExpiresAt = nil }, false},
+		{"not valid yet", func(c *security.Claims) { c.NotBefore = at(time.Minute) }, false},
+		{"not valid yet within leeway", func(c *security.Claims) { c.NotBefore = at(leeway) }, true},
+		{"not valid until just past leeway", func(c *security.Claims) { c.NotBefore = at(leeway + time.Second) }, false},
+		{"no not-before", func(c *security.Claims) { c.NotBefore = nil }, true},
+		{"issued in the future", func(c *security.Claims) { c.IssuedAt = at(time.Minute) }, false},
+		{"issued in the future within leeway", func(c *security.Claims) { c.IssuedAt = at(leeway) }, true},
+		{"wrong issuer", func(c *security.Claims) { c.Issuer = "someone-else" }, false},
+		{"no issuer", func(c *security.Claims) { c.Issuer = "" }, false},
+		{"wrong audience", func(c *security.Claims) { c.Audience = jwt.ClaimStrings{"billing"} }, false},
+		{"no audience", func(c *security.Claims) { c.Audience = nil }, false},
+		{"another accepted audience", func(c *security.Claims) { c.Audience = jwt.ClaimStrings{"billing", "reports"} }, true},
+		{"no subject", func(c *security.Claims) { c.Subject = "" }, false},
+		{"no token ID", func(c *security.Claims) { c.ID = "" }, false},
+		{"no session", func(c *security.Claims) { c.SessionID = "" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.change(&claims)
+			err := claims.Validate(options, now)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, security.ErrInvalidClaims)
+			}
+		})
+	}
+}
+
+func TestClaimsValidateWithoutIssuerOrAudience(t *testing.T) {
+	now := time.Now()
+	claims := security.Claims{
+		SessionID: "session",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ID:        "token",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+	assert.NoError(t, claims.Validate(security.TokenOptions{}, now))
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"fmt"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestIncludesRequireThePermissionOfTheirResource(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme := createCompany(t, db, "Acme")
+	createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.TenantAdmin, CompanyID: &acme.ID}, "secret-password")
+	createUser(t, db, models.User{Name: "Alan", Email: "alan@acme.com", Role: enum.User, CompanyID: &acme.ID}, "password")
+	admin := login(t, handler, "ada@acme.com", "secret-password")["access_token"].(string)
+	user := login(t, handler, "alan@acme.com", "password")["access_token"].(string)
+	path := fmt.Sprintf("/company/%d", acme.ID)
+
+	// Members are listed with users:list, whichever way they're asked for
+	w := serve(handler, http.MethodGet, path+"/users", user, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = serve(handler, http.MethodGet, path+"?include=users", user, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "ada@acme.com")
+
+	w = serve(handler, http.MethodGet, path, user, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, decode(t, w)["company"], "users")
+
+	w = serve(handler, http.MethodGet, path+"?include=users", admin, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, decode(t, w)["company"].(map[string]interface{})["users"], 2)
+}
+
+// newCompanyApp signs in a super admin of Acme, and returns Acme and Globex.
+func newCompanyApp(t *testing.T) (http.Handler, *gorm.DB, string, models.Company, models.Company) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme, globex := createCompany(t, db, "Acme"), createCompany(t, db, "Globex")
+	createUser(t, db, models.User{Name: "Root", Email: "root@acme.com", Role: enum.Admin, CompanyID: &acme.ID}, "root-password")
+	token := login(t, handler, "root@acme.com", "root-password")["access_token"].(string)
+	return handler, db, token, acme, globex
+}
+
+func TestGetCompany(t *testing.T) {
+	handler, _, token, acme, _ := newCompanyApp(t)
+	path := fmt.Sprintf("/company/%d", acme.ID)
+
+	w := serve(handler, http.MethodGet, path, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	company := decode(t, w)["company"].(map[string]interface{})
+	assert.Equal(t, "Acme", company["name"])
+	assert.EqualValues(t, acme.ID, company["id"])
+
+	w = serve(handler, http.MethodGet, path, token, nil, "If-None-Match", `"1"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve(handler, http.MethodGet, "/company/999", token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestUpdateCompany(t *testing.T) {
+	handler, db, token, acme, _ := newCompanyApp(t)
+	path := fmt.Sprintf("/company/%d", acme.ID)
+
+	tests := []struct {
+		name    string
+		method  string
+		body    interface{}
+		ifMatch string
+		want    int
+	}{
+		{"PUT requires the name", http.MethodPut, map[string]interface{}{}, `"1"`, http.StatusUnprocessableEntity},
+		{"PUT requires If-Match", http.MethodPut, map[string]interface{}{"name": "Acme Corp"}, "", http.StatusPreconditionRequired},
+		{"PUT on a stale version", http.MethodPut, map[string]interface{}{"name": "Acme Corp"}, `"7"`, http.StatusPreconditionFailed},
+		{"PATCH without changes", http.MethodPatch, map[string]interface{}{}, `"1"`, http.StatusOK},
+		{"PUT", http.MethodPut, map[string]interface{}{"name": "Acme Corp"}, `"1"`, http.StatusOK},
+		{"PATCH", http.MethodPatch, map[string]interface{}{"name": "Acme Inc"}, `"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.ifMatch != "" {
+				headers = []string{"If-Match", tt.ifMatch}
+			}
+			w := serve(handler, tt.method, path, token, tt.body, headers...)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+
+	var company models.Company
+	require.NoError(t, db.First(&company, acme.ID).Error)
+	assert.Equal(t, "Acme Inc", company.Name)
+	assert.EqualValues(t, 3, company.Version)
+
+	w := serve(handler, http.MethodPut, "/company/999", token, map[string]interface{}{"name": "Initech"}, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestCompanyMembers(t *testing.T) {
+	handler, db, token, acme, globex := newCompanyApp(t)
+	alan := createUser(t, db, models.User{Name: "Alan", Email: "alan@globex.com", Role: enum.User, CompanyID: &globex.ID}, "password")
+	members := func(company models.Company) []string {
+		w := serve(handler, http.MethodGet, fmt.Sprintf("/company/%d/users", company.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var emails []string
+		for _, user := range decode(t, w)["users"].([]interface{}) {
+			emails = append(emails, user.(map[string]interface{})["email"].(string))
+		}
+		return emails
+	}
+	assert.Equal(t, []string{"root@acme.com"}, members(acme))
+	assert.Equal(t, []string{"alan@globex.com"}, members(globex))
+
+	member := fmt.Sprintf("/company/%d/users/%d", acme.ID, alan.ID)
+	w := serve(handler, http.MethodPost, member, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.ElementsMatch(t, []string{"root@acme.com", "alan@globex.com"}, members(acme))
+	assert.Empty(t, members(globex))
+
+	w = serve(handler, http.MethodDelete, member, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"root@acme.com"}, members(acme))
+
+	var removed models.User
+	require.NoError(t, db.First(&removed, alan.ID).Error)
+	assert.Nil(t, removed.CompanyID)
+	assert.EqualValues(t, 3, removed.Version, "both moves bump the version")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"members of a missing company", http.MethodGet, "/company/999/users"},
+		{"add to a missing company", http.MethodPost, fmt.Sprintf("/company/999/users/%d", alan.ID)},
+		{"add a missing user", http.MethodPost, fmt.Sprintf("/company/%d/users/999", acme.ID)},
+		{"remove from a missing company", http.MethodDelete, fmt.Sprintf("/company/999/users/%d", alan.ID)},
+		{"remove a missing user", http.MethodDelete, fmt.Sprintf("/company/%d/users/999", acme.ID)},
+		{"remove a user who isn't a member", http.MethodDelete, member},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.method, tt.path, token, nil)
+			assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+		})
+	}
+}
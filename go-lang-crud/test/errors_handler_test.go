@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/middlewares"
+	"golang-crud/models"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newErrorRouter(err error) *gin.Engine {
+	router := gin.New()
+	router.Use(middlewares.RequestID(), middlewares.ErrorHandler())
+	router.GET("/fail", func(c *gin.Context) {
+		c.Error(err)
+	})
+	router.GET("/written", func(c *gin.Context) {
+		c.Error(err)
+		c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+	})
+	return router
+}
+
+func TestErrorHandler(t *testing.T) {
+	secret := errors.New(`pq: password authentication failed for user "app"`)
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+		errors interface{}
+	}{
+		{"domain error", custom_error.ErrUserNotFound, http.StatusNotFound, "user not found", nil},
+		{"domain error with context", fmt.Errorf("user with ID 7 not found: %w", custom_error.ErrUserNotFound), http.StatusNotFound, "user with ID 7 not found: user not found", nil},
+		{"domain error with a cause", custom_error.Wrap(custom_error.KindConflict, "Record is still referenced by other records", secret), http.StatusConflict, "Record is still referenced by other records", nil},
+		{"validation error", custom_error.Validation("Validation failed", []interface{}{"details"}), http.StatusUnprocessableEntity, "Validation failed", []interface{}{"details"}},
+		{"internal domain error", custom_error.Wrap(custom_error.KindInternal, "Could not connect", secret), http.StatusInternalServerError, "", nil},
+		{"plain error", secret, http.StatusInternalServerError, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(newErrorRouter(tt.err), http.MethodGet, "/fail", "", nil, middlewares.RequestIDHeader, "req-42")
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.NotContains(t, w.Body.String(), "password authentication", "causes are only logged")
+
+			var problem map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			want := map[string]interface{}{
+				"type":       "about:blank",
+				"title":      http.StatusText(tt.status),
+				"status":     float64(tt.status),
+				"instance":   "/fail",
+				"request_id": "req-42",
+			}
+			if tt.detail != "" {
+				want["detail"] = tt.detail
+			}
+			if tt.errors != nil {
+				want["errors"] = tt.errors
+			}
+			assert.Equal(t, want, problem)
+		})
+	}
+
+	// A response already written is left alone
+	w := serve(newErrorRouter(secret), http.MethodGet, "/written", "", nil)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"status":"queued"}`, w.Body.String())
+}
+
+func TestRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	tests := []struct {
+		name, header string
+		kept         bool
+	}{
+		{"none", "", false},
+		{"valid", "3f2b-9c1a_trace.1", true},
+		{"with spaces", "abc def", false},
+		{"log injection", "abc\tlevel=error", false},
+		{"quotes", `abc"}`, false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"longest", strings.Repeat("a", 128), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(newErrorRouter(custom_error.ErrUserNotFound), http.MethodGet, "/fail", "", nil, middlewares.RequestIDHeader, tt.header)
+			id := w.Header().Get(middlewares.RequestIDHeader)
+			if tt.kept {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.Regexp(t, generated, id)
+			}
+			var problem middlewares.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, id, problem.RequestID)
+		})
+	}
+
+	first := serve(newErrorRouter(custom_error.ErrUserNotFound), http.MethodGet, "/fail", "", nil)
+	second := serve(newErrorRouter(custom_error.ErrUserNotFound), http.MethodGet, "/fail", "", nil)
+	assert.NotEqual(t, first.Header().Get(middlewares.RequestIDHeader), second.Header().Get(middlewares.RequestIDHeader))
+}
+
+func TestDeleteMissingUser(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme := createCompany(t, db, "Acme")
+	createUser(t, db, models.User{Name: "Root", Email: "root@acme.com", Role: enum.Admin, CompanyID: &acme.ID}, "root-password")
+	token := login(t, handler, "root@acme.com", "root-password")["access_token"].(string)
+
+	// IDs are bound as parameters, "1 OR 1=1" doesn't find user 1
+	for _, path := range []string{"/user/999", "/user/not-a-number", "/user/1%20OR%201=1"} {
+		w := serve(handler, http.MethodDelete, path, token, nil, "If-Match", `"1"`)
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"golang-crud/custom_error"
+	"golang-crud/models"
+	"golang-crud/repository"
+	"golang-crud/tenant"
+	"golang-crud/validation"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// failingCreates makes every insert on db fail with the error err points to,
+// standing in for the errors Postgres reports.
+func failingCreates(t *testing.T, db *gorm.DB, err *error) {
+	require.NoError(t, db.Callback().Create().Replace("gorm:create", func(tx *gorm.DB) {
+		tx.AddError(*err)
+	}))
+}
+
+func TestConstraintViolations(t *testing.T) {
+	db := newDB(t)
+	var pgErr error
+	failingCreates(t, db, &pgErr)
+	companies := repository.NewCompanyRepository(db)
+
+	tests := []struct {
+		name    string
+		err     *pgconn.PgError
+		status  int
+		message string
+		fields  validation.Errors
+	}{
+		{
+			"duplicate value",
+			&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email", Detail: "Key (email)=(ada@acme.com) already exists."},
+			http.StatusConflict, "email already exists",
+			validation.Errors{{Field: "email", Rule: "unique", Message: "is already taken"}},
+		},
+		{
+			"duplicate composite value",
+			&pgconn.PgError{Code: "23505", ConstraintName: "idx_identities", Detail: "Key (provider, subject)=(github, 42) already exists."},
+			http.StatusConflict, "provider already exists",
+			validation.Errors{{Field: "provider", Rule: "unique", Message: "is already taken"}, {Field: "subject", Rule: "unique", Message: "is already taken"}},
+		},
+		{
+			"duplicate without detail",
+			&pgconn.PgError{Code: "23505", ConstraintName: "uni_companies_name"},
+			http.StatusConflict, "uni_companies_name already exists",
+			validation.Errors{{Field: "uni_companies_name", Rule: "unique", Message: "is already taken"}},
+		},
+		{
+			"reference to a missing record",
+			&pgconn.PgError{Code: "23503", ConstraintName: "fk_users_company", Detail: `Key (company_id)=(7) is not present in table "companies".`},
+			http.StatusUnprocessableEntity, "Validation failed",
+			validation.Errors{{Field: "company_id", Rule: "exists", Message: "does not exist"}},
+		},
+		{
+			"reference without detail",
+			&pgconn.PgError{Code: "23503", ConstraintName: "fk_posts_user"},
+			http.StatusUnprocessableEntity, "Validation failed",
+			validation.Errors{{Field: "fk_posts_user", Rule: "exists", Message: "does not exist"}},
+		},
+		{
+			"record still referenced",
+			&pgconn.PgError{Code: "23503", ConstraintName: "fk_companies_users", Detail: `Key (id)=(1) is still referenced from table "users".`},
+			http.StatusConflict, "Record is still referenced by other records", nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgErr = tt.err
+			err := companies.Create(context.Background(), &models.Company{Name: "Acme"})
+
+			var domainErr *custom_error.Error
+			require.True(t, errors.As(err, &domainErr), "%v", err)
+			assert.Equal(t, tt.status, domainErr.Kind.Status())
+			assert.Equal(t, tt.message, domainErr.Message)
+			if tt.fields != nil {
+				assert.Equal(t, tt.fields, domainErr.Details)
+			} else {
+				assert.Nil(t, domainErr.Details)
+			}
+			assert.ErrorIs(t, err, tt.err, "the cause is kept for the logs")
+		})
+	}
+
+	// Other database errors are passed through
+	pgErr = &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+	err := companies.Create(context.Background(), &models.Company{Name: "Acme"})
+	var domainErr *custom_error.Error
+	assert.False(t, errors.As(err, &domainErr))
+	assert.ErrorIs(t, err, pgErr)
+}
+
+func TestMalformedIDsAreNotFound(t *testing.T) {
+	db := newDB(t)
+	// Postgres refuses to compare an integer column with text that isn't a number
+	require.NoError(t, db.Callback().Query().Replace("gorm:query", func(tx *gorm.DB) {
+		tx.AddError(&pgconn.PgError{Code: "22P02", Message: `invalid input syntax for type bigint: "abc"`})
+	}))
+
+	_, err := repository.NewCompanyRepository(db).FindById(tenant.System(context.Background()), "abc", nil)
+	assert.ErrorIs(t, err, custom_error.ErrCompanyNotFound)
+}
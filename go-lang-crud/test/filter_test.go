@@ -0,0 +1,134 @@
+package test
+
+import (
+	"golang-crud/filter"
+	"golang-crud/models"
+	"golang-crud/pagination"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testQuery = filter.Resource{
+	Fields: map[string]filter.Field{
+		"id":         {Column: "id", Type: filter.Number, Sortable: true},
+		"name":       {Column: "name", Type: filter.String, Sortable: true},
+		"role":       {Column: "role", Type: filter.String},
+		"created_at": {Column: "created_at", Type: filter.Time, Sortable: true},
+	},
+	DefaultSort: []pagination.Column{{Name: "id"}},
+}
+
+func TestParseFilters(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query string
+		want  []filter.Condition
+	}{
+		{"", nil},
+		{"filter[role]=admin", []filter.Condition{{Column: "role", Operator: filter.Eq, Value: "admin"}}},
+		{"filter[role][ne]=admin", []filter.Condition{{Column: "role", Operator: filter.Ne, Value: "admin"}}},
+		{"filter[id][gte]=5", []filter.Condition{{Column: "id", Operator: filter.Gte, Value: int64(5)}}},
+		{"filter[id][lt]=2.5", []filter.Condition{{Column: "id", Operator: filter.Lt, Value: 2.5}}},
+		{"filter[id][in]=1,2", []filter.Condition{{Column: "id", Operator: filter.In, Value: []interface{}{int64(1), int64(2)}}}},
+		{"filter[created_at][gt]=2024-01-02", []filter.Condition{{Column: "created_at", Operator: filter.Gt, Value: day}}},
+		{"filter[created_at][lte]=2024-01-02T00:00:00Z", []filter.Condition{{Column: "created_at", Operator: filter.Lte, Value: day}}},
+		{"filter[name][like]=ada", []filter.Condition{{Column: "name", Operator: filter.Like, Value: "ada"}}},
+		// Repeated parameters are ANDed, keys are applied in sorted order
+		{"filter[role]=a&filter[id]=1&filter[role]=b", []filter.Condition{
+			{Column: "id", Operator: filter.Eq, Value: int64(1)},
+			{Column: "role", Operator: filter.Eq, Value: "a"},
+			{Column: "role", Operator: filter.Eq, Value: "b"},
+		}},
+		// Other parameters are ignored
+		{"page_size=10&fields=id", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			query, err := testQuery.Parse(values)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, query.Conditions)
+			assert.Equal(t, testQuery.DefaultSort, query.Sort)
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	query, err := testQuery.Parse(url.Values{"sort": {"-created_at,name"}})
+	require.NoError(t, err)
+	assert.Equal(t, []pagination.Column{{Name: "created_at", Desc: true}, {Name: "name"}}, query.Sort)
+}
+
+func TestParseRejectsQueriesOutsideTheWhitelist(t *testing.T) {
+	tests := []string{
+		"filter[password]=x",
+		"filter[]=x",
+		"filter[role][gt][x]=a",
+		"filter[role][gt]=a",
+		"filter[role][regex]=a",
+		"filter[created_at][like]=2024",
+		"filter[created_at][in]=2024-01-01",
+		"filter[id][like]=1",
+		"filter[id]=one",
+		"filter[id][in]=1,two",
+		"filter[created_at]=yesterday",
+		"sort=password",
+		"sort=role",
+		"sort=name,-role",
+	}
+	for _, raw := range tests {
+		t.Run(raw, func(t *testing.T) {
+			values, err := url.ParseQuery(raw)
+			require.NoError(t, err)
+			_, err = testQuery.Parse(values)
+			assert.ErrorIs(t, err, filter.ErrInvalidQuery)
+		})
+	}
+}
+
+// statement builds the query a filter produces without running it, ILIKE is Postgres only
+func statement(t *testing.T, db *gorm.DB, raw string) *gorm.Statement {
+	values, err := url.ParseQuery(raw)
+	require.NoError(t, err)
+	query, err := testQuery.Parse(values)
+	require.NoError(t, err)
+	tx := db.Session(&gorm.Session{DryRun: true}).Scopes(query.Scope).Find(&[]models.User{})
+	require.NoError(t, tx.Error)
+	return tx.Statement
+}
+
+func TestLikeFilterEscapesWildcards(t *testing.T) {
+	db := newEmptyDB(t)
+	tests := []struct {
+		value, want string
+	}{
+		{"ada", "%ada%"},
+		{"100%", `%100\%%`},
+		{"a_b", `%a\_b%`},
+		{`C:\temp`, `%C:\\temp%`},
+		{`\%_`, `%\\\%\_%`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			stmt := statement(t, db, url.Values{"filter[name][like]": {tt.value}}.Encode())
+			assert.Contains(t, stmt.SQL.String(), "`name` ILIKE ?")
+			assert.Equal(t, []interface{}{tt.want}, stmt.Vars)
+		})
+	}
+}
+
+func TestFilterScope(t *testing.T) {
+	db := newEmptyDB(t)
+	stmt := statement(t, db, "filter[role][ne]=admin&filter[id][in]=1,2")
+	assert.Contains(t, stmt.SQL.String(), "WHERE `id` IN (?,?) AND `role` <> ?")
+	assert.Equal(t, []interface{}{int64(1), int64(2), "admin"}, stmt.Vars)
+
+	var query *filter.Query
+	assert.Same(t, db, query.Scope(db))
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"golang-crud/controllers"
+	"golang-crud/health"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubChecker fails with err after delay, or when the check is given up on.
+type stubChecker struct {
+	delay time.Duration
+	err   error
+}
+
+func (s stubChecker) Check(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	broken := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		checkers map[string]health.Checker
+		want     health.Status
+		down     []string
+	}{
+		{"nothing to check", nil, health.Up, nil},
+		{"all up", map[string]health.Checker{"a": stubChecker{}, "b": stubChecker{delay: 10 * time.Millisecond}}, health.Up, nil},
+		{"one down", map[string]health.Checker{"a": stubChecker{}, "b": stubChecker{err: broken}}, health.Down, []string{"b"}},
+		{"one too slow", map[string]health.Checker{"a": stubChecker{}, "b": stubChecker{delay: time.Minute}}, health.Down, []string{"b"}},
+		{"all down", map[string]health.Checker{"a": stubChecker{err: broken}, "b": stubChecker{delay: time.Minute}}, health.Down, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := health.New(50 * time.Millisecond)
+			for name, checker := range tt.checkers {
+				checks.Register(name, checker)
+			}
+
+			start := time.Now()
+			report := checks.Check(context.Background())
+			assert.Less(t, time.Since(start), time.Second, "slow checkers are given up on at the timeout")
+
+			assert.Equal(t, tt.want, report.Status)
+			require.Len(t, report.Components, len(tt.checkers))
+			var down []string
+			for name, component := range report.Components {
+				if component.Status == health.Down {
+					down = append(down, name)
+					assert.Error(t, component.Err)
+				}
+			}
+			assert.ElementsMatch(t, tt.down, down)
+		})
+	}
+}
+
+func TestHealthCheckTimeouts(t *testing.T) {
+	checks := health.New(20 * time.Millisecond)
+	checks.Register("slow", stubChecker{delay: time.Minute})
+	report := checks.Check(context.Background())
+	assert.ErrorIs(t, report.Components["slow"].Err, context.DeadlineExceeded)
+
+	// A checker that ignores its context is given up on all the same
+	checks.Register("slow", health.CheckerFunc(func(context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}))
+	start := time.Now()
+	report = checks.Check(context.Background())
+	assert.Equal(t, health.Down, report.Status)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestReadiness(t *testing.T) {
+	checks := health.New(time.Second)
+	checks.Register("database", stubChecker{})
+	router := gin.New()
+	router.GET("/readyz", controllers.NewHealthController(checks).Readiness)
+
+	w := serve(router, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "up", decode(t, w)["status"])
+
+	checks.Register("cache", stubChecker{err: errors.New("dial tcp 10.0.0.7:6379: connection refused")})
+	w = serve(router, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+	body := decode(t, w)
+	assert.Equal(t, "down", body["status"])
+	assert.Equal(t, "down", body["components"].(map[string]interface{})["cache"].(map[string]interface{})["status"])
+	assert.NotContains(t, w.Body.String(), "10.0.0.7", "errors stay in the logs")
+}
+
+func TestAppReadiness(t *testing.T) {
+	// The schema was created without the migrations, so they are all pending
+	handler := newApp(t, newDB(t)).Handler()
+
+	w := serve(handler, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+	components := decode(t, w)["components"].(map[string]interface{})
+	assert.Equal(t, "up", components["database"].(map[string]interface{})["status"])
+	assert.Equal(t, "down", components["migrations"].(map[string]interface{})["status"])
+	assert.Len(t, components, 2)
+
+	w = serve(handler, http.MethodGet, "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
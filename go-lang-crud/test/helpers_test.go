@@ -0,0 +1,133 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"golang-crud/app"
+	"golang-crud/config"
+	"golang-crud/models"
+	"golang-crud/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newDB returns an in-memory SQLite database with the application's tables,
+// so tests run without Postgres.
+func newDB(t *testing.T) *gorm.DB {
+	db := newEmptyDB(t)
+	require.NoError(t, db.AutoMigrate(
+		&models.Company{}, &models.User{}, &models.Post{}, &models.Permission{}, &models.Role{},
+		&models.RefreshToken{}, &models.ExternalIdentity{},
+	))
+	return db
+}
+
+// newEmptyDB returns an in-memory SQLite database without tables.
+func newEmptyDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	// Every connection to :memory: opens a new, empty database
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// newApp builds the application on db with the test profile's defaults.
+func newApp(t *testing.T, db *gorm.DB, opts ...app.Option) *app.App {
+	cfg := config.Defaults(config.Test)
+	return newAppWithConfig(t, &cfg, db, opts...)
+}
+
+// newAppWithConfig builds the application on db with cfg.
+func newAppWithConfig(t *testing.T, cfg *config.Config, db *gorm.DB, opts ...app.Option) *app.App {
+	application, err := app.New(cfg, append([]app.Option{app.WithDB(db), app.WithoutMigrations()}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { application.Shutdown(context.Background()) })
+	return application
+}
+
+// createUser stores the user with the password hashed.
+func createUser(t *testing.T, db *gorm.DB, user models.User, password string) models.User {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	user.Password = string(hashed)
+	require.NoError(t, db.Create(&user).Error)
+	return user
+}
+
+func createCompany(t *testing.T, db *gorm.DB, name string) models.Company {
+	company := models.Company{Name: name}
+	require.NoError(t, db.Create(&company).Error)
+	return company
+}
+
+// serve sends the request to handler, as JSON if body isn't nil, with token
+// as bearer if set.
+func serve(handler http.Handler, method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, _ := json.Marshal(body)
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// login signs in with a password and returns the token pair.
+func login(t *testing.T, handler http.Handler, email, password string) map[string]interface{} {
+	w := serve(handler, http.MethodPost, "/login", "", map[string]string{"email": email, "password": password})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return decode(t, w)
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	return body
+}
+
+func newUserRepository(db *gorm.DB) repository.UserRepository {
+	return repository.NewUserRepository(db)
+}
+
+// countingUsers counts the lookups by email made through it.
+type countingUsers struct {
+	repository.UserRepository
+	byEmail int
+}
+
+func (r *countingUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.byEmail++
+	return r.UserRepository.FindByEmail(ctx, email)
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/oauth"
+	"golang-crud/repository"
+	"golang-crud/service"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newIdentityService(db *gorm.DB, options service.ProvisioningOptions) *service.IdentityService {
+	return service.NewIdentityService(repository.NewExternalIdentityRepository(db), repository.NewUserRepository(db), options)
+}
+
+func identityCount(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	require.NoError(t, db.Model(&models.ExternalIdentity{}).Count(&count).Error)
+	return count
+}
+
+func TestResolveUserLinksVerifiedEmailFromTrustedProvider(t *testing.T) {
+	db := newDB(t)
+	victim := createUser(t, db, models.User{Name: "Admin", Email: "admin@acme.com", Role: enum.Admin}, "password")
+	identities := newIdentityService(db, service.ProvisioningOptions{TrustedProviders: []string{"google"}})
+
+	user, err := identities.ResolveUser(context.Background(), service.ExternalAccount{Provider: "google", Subject: "g-1", Email: "admin@acme.com", EmailVerified: true})
+	require.NoError(t, err)
+	assert.Equal(t, victim.ID, user.ID)
+	assert.EqualValues(t, 1, identityCount(t, db))
+
+	// Later sign-ins resolve through the link
+	user, err = identities.ResolveUser(context.Background(), service.ExternalAccount{Provider: "google", Subject: "g-1"})
+	require.NoError(t, err)
+	assert.Equal(t, victim.ID, user.ID)
+}
+
+func TestResolveUserRefusesUntrustedEmails(t *testing.T) {
+	tests := []struct {
+		name    string
+		account service.ExternalAccount
+	}{
+		{"unverified email", service.ExternalAccount{Provider: "google", Subject: "g-1", Email: "admin@acme.com"}},
+		{"provider not trusted", service.ExternalAccount{Provider: "gitlab", Subject: "gl-1", Email: "admin@acme.com", EmailVerified: true}},
+		{"no email", service.ExternalAccount{Provider: "google", Subject: "g-2", EmailVerified: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDB(t)
+			createUser(t, db, models.User{Name: "Admin", Email: "admin@acme.com", Role: enum.Admin}, "password")
+			identities := newIdentityService(db, service.ProvisioningOptions{TrustedProviders: []string{"google"}})
+
+			_, err := identities.ResolveUser(context.Background(), tt.account)
+			assert.ErrorIs(t, err, custom_error.ErrProvisioningDenied)
+			assert.EqualValues(t, 0, identityCount(t, db))
+		})
+	}
+}
+
+func TestResolveUserProvisionsOnlyVerifiedEmails(t *testing.T) {
+	db := newDB(t)
+	company := createCompany(t, db, "Acme")
+	identities := newIdentityService(db, service.ProvisioningOptions{
+		Enabled:          true,
+		DefaultRole:      enum.User,
+		DomainCompanies:  map[string]uint{"acme.com": company.ID},
+		TrustedProviders: []string{"oidc"},
+	})
+
+	_, err := identities.ResolveUser(context.Background(), service.ExternalAccount{Provider: "oidc", Subject: "o-1", Email: "new@acme.com"})
+	assert.ErrorIs(t, err, custom_error.ErrProvisioningDenied)
+
+	user, err := identities.ResolveUser(context.Background(), service.ExternalAccount{Provider: "oidc", Subject: "o-1", Email: "new@acme.com", EmailVerified: true})
+	require.NoError(t, err)
+	assert.Equal(t, "new@acme.com", user.Email)
+	require.NotNil(t, user.CompanyID)
+	assert.Equal(t, company.ID, *user.CompanyID)
+}
+
+func TestLinkAccountIgnoresEmail(t *testing.T) {
+	db := newDB(t)
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.User}, "password")
+	identities := newIdentityService(db, service.ProvisioningOptions{})
+
+	account := service.ExternalAccount{Provider: "github", Subject: "gh-1", Email: "someone-else@example.com"}
+	linked, err := identities.LinkAccount(context.Background(), user.ID, account)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, linked.ID)
+
+	resolved, err := identities.ResolveUser(context.Background(), account)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, resolved.ID)
+}
+
+func TestEmailVerified(t *testing.T) {
+	tests := []struct {
+		raw  map[string]interface{}
+		want bool
+	}{
+		{map[string]interface{}{"email_verified": true}, true},
+		{map[string]interface{}{"email_verified": "true"}, true},
+		{map[string]interface{}{"verified_email": true}, true},
+		{map[string]interface{}{"email_verified": false}, false},
+		{map[string]interface{}{"email_verified": "false"}, false},
+		{map[string]interface{}{}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, oauth.EmailVerified(goth.User{RawData: tt.raw}), "%v", tt.raw)
+	}
+}
@@ -0,0 +1,232 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"golang-crud/security"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeyManager(t *testing.T, method jwt.SigningMethod) *security.KeyManager {
+	keys := security.NewKeyManager()
+	_, err := keys.Rotate(method)
+	require.NoError(t, err)
+	return keys
+}
+
+func sign(t *testing.T, keys *security.KeyManager, subject string) string {
+	signed, err := keys.Sign(jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": subject}))
+	require.NoError(t, err)
+	return signed
+}
+
+func parse(keys *security.KeyManager, signed string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(signed, claims, keys.Keyfunc)
+	return claims, err
+}
+
+func TestSignAndParse(t *testing.T) {
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodEdDSA} {
+		t.Run(method.Alg(), func(t *testing.T) {
+			keys := newKeyManager(t, method)
+			signed := sign(t, keys, "42")
+
+			token, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+			require.NoError(t, err)
+			current, err := keys.Current()
+			require.NoError(t, err)
+			assert.Equal(t, method.Alg(), token.Header["alg"], "the key decides the algorithm")
+			assert.Equal(t, current.ID, token.Header["kid"])
+
+			claims, err := parse(keys, signed)
+			require.NoError(t, err)
+			assert.Equal(t, "42", claims["sub"])
+
+			_, err = parse(newKeyManager(t, method), signed)
+			assert.Error(t, err, "another manager doesn't know the kid")
+		})
+	}
+}
+
+func TestRotateKeepsRetiredKeysVerifying(t *testing.T) {
+	keys := newKeyManager(t, jwt.SigningMethodRS256)
+	first, err := keys.Current()
+	require.NoError(t, err)
+	before := sign(t, keys, "42")
+
+	second, err := keys.Rotate(jwt.SigningMethodEdDSA)
+	require.NoError(t, err)
+	assert.Equal(t, security.KeyRetired, first.Status)
+	assert.Equal(t, security.KeyActive, second.Status)
+	current, err := keys.Current()
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, current.ID)
+
+	after := sign(t, keys, "42")
+	for _, signed := range []string{before, after} {
+		_, err := parse(keys, signed)
+		assert.NoError(t, err)
+	}
+
+	assert.Error(t, keys.Remove(second.ID), "the signing key can't be removed")
+	require.NoError(t, keys.Remove(first.ID))
+	_, err = parse(keys, before)
+	assert.Error(t, err, "tokens of removed keys stop verifying")
+	_, err = parse(keys, after)
+	assert.NoError(t, err)
+}
+
+func TestKeyfuncRejectsForgedHeaders(t *testing.T) {
+	keys := newKeyManager(t, jwt.SigningMethodRS256)
+	current, err := keys.Current()
+	require.NoError(t, err)
+	public := x509.MarshalPKCS1PublicKey(current.PublicKey().(*rsa.PublicKey))
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	forge := func(method jwt.SigningMethod, kid interface{}, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "42"})
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	tests := map[string]string{
+		"no kid":      forge(jwt.SigningMethodRS256, nil, current.PrivateKey),
+		"empty kid":   forge(jwt.SigningMethodRS256, "", current.PrivateKey),
+		"numeric kid": forge(jwt.SigningMethodRS256, 1, current.PrivateKey),
+		"unknown kid": forge(jwt.SigningMethodRS256, "unknown", current.PrivateKey),
+		// The public key is public, signing HMAC with it must not pass
+		"HS256 with the public key": forge(jwt.SigningMethodHS256, current.ID, public),
+		"EdDSA under an RSA kid":    forge(jwt.SigningMethodEdDSA, current.ID, edKey),
+		"none":                      forge(jwt.SigningMethodNone, current.ID, jwt.UnsafeAllowNoneSignatureType),
+	}
+	for name, signed := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parse(keys, signed)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func writeKey(t *testing.T, dir, kid string, key interface{}) {
+	var block *pem.Block
+	if private, ok := key.(*rsa.PrivateKey); ok {
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600))
+}
+
+func TestLoadKeyManager(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeKey(t, dir, "2024-01", rsaKey)
+	writeKey(t, dir, "2024-02", edKey)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600))
+
+	tests := []struct {
+		activeKID, want string
+		method          jwt.SigningMethod
+	}{
+		{"", "2024-02", jwt.SigningMethodEdDSA},
+		{"2024-01", "2024-01", jwt.SigningMethodRS256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			keys, err := security.LoadKeyManager(dir, tt.activeKID)
+			require.NoError(t, err)
+			current, err := keys.Current()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, current.ID)
+			assert.Equal(t, tt.method, current.Method)
+
+			require.Len(t, keys.Keys(), 2)
+			for _, key := range keys.Keys() {
+				if key.ID != tt.want {
+					assert.Equal(t, security.KeyRetired, key.Status)
+				}
+			}
+		})
+	}
+
+	_, err = security.LoadKeyManager(dir, "2023-12")
+	assert.ErrorContains(t, err, `"2023-12" not found`)
+	_, err = security.LoadKeyManager(t.TempDir(), "")
+	assert.ErrorContains(t, err, "no signing keys")
+
+	weak := t.TempDir()
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	writeKey(t, weak, "weak", weakKey)
+	_, err = security.LoadKeyManager(weak, "")
+	assert.ErrorContains(t, err, "at least 2048 bits")
+
+	// PKCS#8 doesn't get around the minimum
+	der, err := x509.MarshalPKCS8PrivateKey(weakKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(weak, "weak.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	_, err = security.LoadKeyManager(weak, "")
+	assert.ErrorContains(t, err, "at least 2048 bits")
+}
+
+func TestJWKS(t *testing.T) {
+	keys := newKeyManager(t, jwt.SigningMethodRS256)
+	retired, err := keys.Current()
+	require.NoError(t, err)
+	active, err := keys.Rotate(jwt.SigningMethodEdDSA)
+	require.NoError(t, err)
+
+	set := keys.JWKS()
+	require.Len(t, set.Keys, 2, "retired keys are published until removed")
+	byKID := map[string]security.JWK{}
+	for _, jwk := range set.Keys {
+		byKID[jwk.KeyID] = jwk
+		assert.Equal(t, "sig", jwk.Use)
+	}
+
+	rsaJWK := byKID[retired.ID]
+	rsaPublic := retired.PublicKey().(*rsa.PublicKey)
+	assert.Equal(t, "RSA", rsaJWK.KeyType)
+	assert.Equal(t, "RS256", rsaJWK.Algorithm)
+	assert.Equal(t, "AQAB", rsaJWK.E, "65537, big-endian without padding")
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	require.NoError(t, err)
+	assert.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(rsaPublic.N))
+	assert.Len(t, n, 256, "no leading zero byte")
+	assert.Empty(t, rsaJWK.X)
+
+	edJWK := byKID[active.ID]
+	assert.Equal(t, "OKP", edJWK.KeyType)
+	assert.Equal(t, "Ed25519", edJWK.Curve)
+	assert.Equal(t, "EdDSA", edJWK.Algorithm)
+	x, err := base64.RawURLEncoding.DecodeString(edJWK.X)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(active.PublicKey().(ed25519.PublicKey)), x)
+	assert.Empty(t, edJWK.N)
+
+	require.NoError(t, keys.Remove(retired.ID))
+	assert.Len(t, keys.JWKS().Keys, 1)
+	assert.Equal(t, []security.JWK{}, security.NewKeyManager().JWKS().Keys)
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+	"golang-crud/config"
+	"golang-crud/migrations"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func script(sql string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(sql)}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	loaded, err := migrations.Load(fstest.MapFS{
+		"20240102000000_add_notes.up.sql":   script("ALTER TABLE things ADD COLUMN notes text;"),
+		"20240102000000_add_notes.down.sql": script("ALTER TABLE things DROP COLUMN notes;"),
+		"20240101000000_things.up.sql":      script("CREATE TABLE things (id integer);"),
+		"20240101000000_things.down.sql":    script("DROP TABLE things;"),
+		"README.md":                         script("not a migration"),
+	})
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, "20240101000000_things", loaded[0].String())
+	assert.Equal(t, "CREATE TABLE things (id integer);", loaded[0].Up)
+	assert.Equal(t, "DROP TABLE things;", loaded[0].Down)
+	assert.Equal(t, "20240102000000_add_notes", loaded[1].String())
+}
+
+func TestLoadMigrationsRejectsBrokenSets(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"20240101000000_things.up.sql": script("CREATE TABLE things (id integer);"),
+		}},
+		{"names differ", fstest.MapFS{
+			"20240101000000_things.up.sql":   script("CREATE TABLE things (id integer);"),
+			"20240101000000_others.down.sql": script("DROP TABLE things;"),
+		}},
+		{"version out of range", fstest.MapFS{
+			"99999999999999999999_things.up.sql":   script("CREATE TABLE things (id integer);"),
+			"99999999999999999999_things.down.sql": script("DROP TABLE things;"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrations.Load(tt.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	loaded, err := migrations.Load(migrations.Embedded())
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	assert.Equal(t, "initial_schema", loaded[0].Name)
+}
+
+func testMigrations(t *testing.T) []migrations.Migration {
+	loaded, err := migrations.Load(fstest.MapFS{
+		"1_things.up.sql":      script("CREATE TABLE things (id integer);"),
+		"1_things.down.sql":    script("DROP TABLE things;"),
+		"2_add_notes.up.sql":   script("ALTER TABLE things ADD COLUMN notes text;"),
+		"2_add_notes.down.sql": script("ALTER TABLE things DROP COLUMN notes;"),
+	})
+	require.NoError(t, err)
+	return loaded
+}
+
+func appliedVersions(t *testing.T, db *gorm.DB) []int64 {
+	var versions []int64
+	require.NoError(t, db.Table("schema_migrations").Order("version").Pluck("version", &versions).Error)
+	return versions
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := newEmptyDB(t)
+	migrator := migrations.New(db, testMigrations(t))
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.Equal(t, []int64{1, 2}, appliedVersions(t, db))
+	assert.True(t, db.Migrator().HasColumn("things", "notes"))
+
+	// Nothing is left to apply
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(2), reverted[0].Version)
+	assert.Equal(t, []int64{1}, appliedVersions(t, db))
+	assert.False(t, db.Migrator().HasColumn("things", "notes"))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	pending, err = migrator.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(2), pending[0].Version)
+
+	// Reverting more than was applied stops at the first migration
+	reverted, err = migrator.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.Empty(t, appliedVersions(t, db))
+	assert.False(t, db.Migrator().HasTable("things"))
+}
+
+func TestMigratorStopsAtFailingMigration(t *testing.T) {
+	db := newEmptyDB(t)
+	loaded := append(testMigrations(t), migrations.Migration{Version: 3, Name: "broken", Up: "NOT SQL;", Down: "SELECT 1;"})
+
+	applied, err := migrations.New(db, loaded).Up(context.Background())
+	assert.ErrorContains(t, err, "3_broken")
+	assert.Len(t, applied, 2)
+	assert.Equal(t, []int64{1, 2}, appliedVersions(t, db))
+}
+
+func TestProdDoesNotMigrateAtStartup(t *testing.T) {
+	assert.False(t, config.Defaults(config.Prod).Database.AutoMigrate)
+	assert.True(t, config.Defaults(config.Dev).Database.AutoMigrate)
+}
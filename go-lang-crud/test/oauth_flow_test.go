@@ -0,0 +1,94 @@
+package test
+
+import (
+	"golang-crud/app"
+	"golang-crud/config"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/oauth"
+	"golang-crud/oauth/oauthtest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProviderApp serves an app with the mock provider enabled as "acme",
+// trusted for sign-in by email, and a user ada@acme.com.
+func newProviderApp(t *testing.T, user oauthtest.User) (http.Handler, models.User) {
+	server := oauthtest.NewServer(user)
+	t.Cleanup(server.Close)
+
+	provider, err := oauth.NewProvider(server.ProviderConfig("acme", "http://localhost/auth/acme/callback"))
+	require.NoError(t, err)
+	registry := oauth.NewRegistry("flow-secret")
+	registry.Add(provider)
+
+	cfg := config.Defaults(config.Test)
+	cfg.Provisioning.TrustedProviders = []string{"acme"}
+	db := newDB(t)
+	existing := createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.User}, "secret-password")
+	return newAppWithConfig(t, &cfg, db, app.WithProviders(registry)).Handler(), existing
+}
+
+// completeAtProvider follows authURL to the provider, which approves at
+// once, and sends its redirect back to the callback with the flow cookies.
+func completeAtProvider(t *testing.T, handler http.Handler, authURL string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func signInWithProvider(t *testing.T, handler http.Handler) *httptest.ResponseRecorder {
+	w := serve(handler, http.MethodGet, "/auth/acme/login", "", nil)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+	return completeAtProvider(t, handler, w.Header().Get("Location"), w.Result().Cookies())
+}
+
+func TestProviderSignInLinksVerifiedEmail(t *testing.T) {
+	handler, existing := newProviderApp(t, oauthtest.User{Subject: "acme-1", Email: "ada@acme.com", EmailVerified: true, Name: "Ada"})
+
+	w := signInWithProvider(t, handler)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	body := decode(t, w)
+	assert.EqualValues(t, existing.ID, body["user"].(map[string]interface{})["id"])
+	assert.NotEmpty(t, body["tokens"].(map[string]interface{})["access_token"])
+}
+
+func TestProviderSignInRefusesUnverifiedEmail(t *testing.T) {
+	handler, _ := newProviderApp(t, oauthtest.User{Subject: "acme-1", Email: "ada@acme.com", Name: "Ada"})
+
+	w := signInWithProvider(t, handler)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+}
+
+func TestProviderLinkFromSignedInSession(t *testing.T) {
+	handler, existing := newProviderApp(t, oauthtest.User{Subject: "acme-1", Email: "someone@elsewhere.com", Name: "Ada"})
+	tokens := login(t, handler, "ada@acme.com", "secret-password")
+
+	w := serve(handler, http.MethodPost, "/auth/acme/link", tokens["access_token"].(string), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	linked := completeAtProvider(t, handler, decode(t, w)["authorization_url"].(string), w.Result().Cookies())
+	require.Equal(t, http.StatusOK, linked.Code, linked.Body.String())
+	assert.Equal(t, "Account linked", decode(t, linked)["message"])
+
+	// The account now signs in as the user it was linked to
+	signedIn := signInWithProvider(t, handler)
+	require.Equal(t, http.StatusOK, signedIn.Code, signedIn.Body.String())
+	assert.EqualValues(t, existing.ID, decode(t, signedIn)["user"].(map[string]interface{})["id"])
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"cmp"
+	"encoding/base64"
+	"golang-crud/models"
+	"golang-crud/pagination"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedCompanies creates companies with repeating names and versions so sorts need the id tiebreaker
+func seedCompanies(t *testing.T, db *gorm.DB) []models.Company {
+	companies := []models.Company{
+		{Name: "b", Version: 1}, {Name: "a", Version: 2}, {Name: "b", Version: 2}, {Name: "c", Version: 1},
+		{Name: "a", Version: 1}, {Name: "b", Version: 1}, {Name: "c", Version: 2},
+	}
+	require.NoError(t, db.Create(&companies).Error)
+	return companies
+}
+
+func compareBy(order []pagination.Column) func(a, b models.Company) int {
+	return func(a, b models.Company) int {
+		for _, column := range order {
+			var c int
+			switch column.Name {
+			case "name":
+				c = cmp.Compare(a.Name, b.Name)
+			case "version":
+				c = cmp.Compare(a.Version, b.Version)
+			case "id":
+				c = cmp.Compare(a.ID, b.ID)
+			}
+			if column.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+func ids(companies []models.Company) []uint {
+	result := make([]uint, len(companies))
+	for i, company := range companies {
+		result[i] = company.ID
+	}
+	return result
+}
+
+func TestFindWalksPagesInBothDirections(t *testing.T) {
+	db := newEmptyDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Company{}))
+	companies := seedCompanies(t, db)
+
+	tests := []struct {
+		name  string
+		order []pagination.Column
+	}{
+		{"id", nil},
+		{"name", []pagination.Column{{Name: "name"}}},
+		{"-name", []pagination.Column{{Name: "name", Desc: true}}},
+		{"-version,name", []pagination.Column{{Name: "version", Desc: true}, {Name: "name"}}},
+		{"-version,-name", []pagination.Column{{Name: "version", Desc: true}, {Name: "name", Desc: true}}},
+		{"-id", []pagination.Column{{Name: "id", Desc: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := slices.Clone(companies)
+			slices.SortFunc(want, compareBy(tt.order))
+
+			var pages [][]uint
+			params := pagination.Params{Limit: 2}
+			for {
+				page, err := pagination.Find[models.Company](db, params, tt.order)
+				require.NoError(t, err)
+				assert.EqualValues(t, len(companies), page.Total)
+				assert.Equal(t, len(pages) > 0, page.Prev != "")
+				pages = append(pages, ids(page.Items))
+				if page.Next == "" {
+					break
+				}
+				params = pagination.Params{Limit: 2, After: page.Next}
+			}
+			assert.Equal(t, ids(want), slices.Concat(pages...))
+			require.Len(t, pages, 4)
+
+			// Walk back from the last page, every page comes out the same as going forward
+			page, err := pagination.Find[models.Company](db, pagination.Params{Limit: 2, After: mustCursor(t, db, tt.order, 3)}, tt.order)
+			require.NoError(t, err)
+			require.Equal(t, pages[3], ids(page.Items))
+			for i := 2; i >= 0; i-- {
+				require.NotEmpty(t, page.Prev)
+				page, err = pagination.Find[models.Company](db, pagination.Params{Limit: 2, Before: page.Prev}, tt.order)
+				require.NoError(t, err)
+				assert.Equal(t, pages[i], ids(page.Items))
+				assert.NotEmpty(t, page.Next)
+			}
+			assert.Empty(t, page.Prev)
+		})
+	}
+}
+
+// mustCursor pages forward to the given page and returns the cursor leading to it
+func mustCursor(t *testing.T, db *gorm.DB, order []pagination.Column, index int) string {
+	params := pagination.Params{Limit: 2}
+	for i := 0; i < index; i++ {
+		page, err := pagination.Find[models.Company](db, params, order)
+		require.NoError(t, err)
+		params.After = page.Next
+	}
+	return params.After
+}
+
+func TestFindSeekClauses(t *testing.T) {
+	db := newEmptyDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Company{}))
+	seedCompanies(t, db)
+
+	var queries []string
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}))
+
+	tests := []struct {
+		name     string
+		order    []pagination.Column
+		backward bool
+		want     string
+	}{
+		{"asc forward", []pagination.Column{{Name: "name"}}, false,
+			"WHERE (`name` > ? OR (`name` = ? AND `id` > ?)) AND `companies`.`deleted_at` IS NULL ORDER BY `name`,`id` LIMIT 3"},
+		{"asc backward", []pagination.Column{{Name: "name"}}, true,
+			"WHERE (`name` < ? OR (`name` = ? AND `id` < ?)) AND `companies`.`deleted_at` IS NULL ORDER BY `name` DESC,`id` DESC LIMIT 3"},
+		{"desc forward", []pagination.Column{{Name: "name", Desc: true}}, false,
+			"WHERE (`name` < ? OR (`name` = ? AND `id` > ?)) AND `companies`.`deleted_at` IS NULL ORDER BY `name` DESC,`id` LIMIT 3"},
+		{"desc backward", []pagination.Column{{Name: "name", Desc: true}}, true,
+			"WHERE (`name` > ? OR (`name` = ? AND `id` < ?)) AND `companies`.`deleted_at` IS NULL ORDER BY `name`,`id` DESC LIMIT 3"},
+		{"mixed forward", []pagination.Column{{Name: "version", Desc: true}, {Name: "name"}}, false,
+			"WHERE (`version` < ? OR (`version` = ? AND `name` > ?) OR (`version` = ? AND `name` = ? AND `id` > ?)) AND `companies`.`deleted_at` IS NULL ORDER BY `version` DESC,`name`,`id` LIMIT 3"},
+		{"mixed backward", []pagination.Column{{Name: "version", Desc: true}, {Name: "name"}}, true,
+			"WHERE (`version` > ? OR (`version` = ? AND `name` < ?) OR (`version` = ? AND `name` = ? AND `id` < ?)) AND `companies`.`deleted_at` IS NULL ORDER BY `version`,`name` DESC,`id` DESC LIMIT 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := mustCursor(t, db, tt.order, 1)
+			params := pagination.Params{Limit: 2, After: cursor}
+			if tt.backward {
+				params = pagination.Params{Limit: 2, Before: cursor}
+			}
+			queries = nil
+			_, err := pagination.Find[models.Company](db, params, tt.order)
+			require.NoError(t, err)
+			require.Len(t, queries, 2)
+			assert.Contains(t, queries[1], tt.want)
+		})
+	}
+}
+
+func TestFindRejectsForeignCursors(t *testing.T) {
+	db := newEmptyDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Company{}))
+	seedCompanies(t, db)
+	byName := []pagination.Column{{Name: "name"}}
+	cursor := mustCursor(t, db, byName, 1)
+
+	tests := map[string]struct {
+		cursor string
+		order  []pagination.Column
+	}{
+		"not base64":        {"not a cursor!", byName},
+		"not json":          {base64.RawURLEncoding.EncodeToString([]byte("{")), byName},
+		"other sort":        {cursor, []pagination.Column{{Name: "name", Desc: true}}},
+		"other columns":     {cursor, []pagination.Column{{Name: "version"}}},
+		"wrong value count": {base64.RawURLEncoding.EncodeToString([]byte(`{"o":"name,id","v":["a"]}`)), byName},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := pagination.Find[models.Company](db, pagination.Params{After: tt.cursor}, tt.order)
+			assert.ErrorIs(t, err, pagination.ErrInvalidParams)
+			_, err = pagination.Find[models.Company](db, pagination.Params{Before: tt.cursor}, tt.order)
+			assert.ErrorIs(t, err, pagination.ErrInvalidParams)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"fmt"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/patch"
+	"golang-crud/policy"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Examples from RFC 7396 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			got, err := patch.Apply(patch.MergePatchType, []byte(tt.doc), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+// Examples from RFC 6902 appendix A, and pointer escaping from RFC 6901
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+		err                    error
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"append array element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+		{"add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, patch.ErrPathNotFound},
+		{"add past the end of an array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`, ``, patch.ErrPathNotFound},
+		{"add with leading zero index", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/01","value":"qux"}]`, ``, patch.ErrPathNotFound},
+		{"add without value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, ``, patch.ErrInvalidPatch},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ``, patch.ErrPathNotFound},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, ``, patch.ErrPathNotFound},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"move into itself", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, ``, patch.ErrInvalidPatch},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`, nil},
+		{"test success", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``, patch.ErrTestFailed},
+		{"test null", `{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`, nil},
+		{"failed test rejects the whole patch", `{"foo":"bar"}`, `[{"op":"replace","path":"/foo","value":"baz"},{"op":"test","path":"/foo","value":"bar"}]`, ``, patch.ErrTestFailed},
+		{"escaped slash", `{"a/b":1}`, `[{"op":"test","path":"/a~1b","value":1}]`, `{"a/b":1}`, nil},
+		{"escaped tilde", `{"m~n":8}`, `[{"op":"replace","path":"/m~0n","value":9}]`, `{"m~n":9}`, nil},
+		{"tilde decoded before slash", `{"~1":10,"/":9}`, `[{"op":"test","path":"/~01","value":10}]`, `{"~1":10,"/":9}`, nil},
+		{"path without leading slash", `{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, ``, patch.ErrInvalidPatch},
+		{"unknown op", `{"foo":"bar"}`, `[{"op":"frobnicate","path":"/foo"}]`, ``, patch.ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patch.Apply(patch.JSONPatchType, []byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestPatchContentTypes(t *testing.T) {
+	got, err := patch.Apply("application/json; charset=utf-8", []byte(`{"a":1}`), []byte(`{"a":null}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(got))
+
+	_, err = patch.Apply("text/plain", []byte(`{"a":1}`), []byte(`{"a":null}`))
+	assert.ErrorIs(t, err, patch.ErrUnsupportedType)
+}
+
+func TestChanged(t *testing.T) {
+	changed, err := patch.Changed([]byte(`{"a":1,"b":2,"c":3}`), []byte(`{"a":1,"b":5,"d":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, changed)
+
+	_, err = patch.Changed([]byte(`{"a":1}`), []byte(`[1]`))
+	assert.ErrorIs(t, err, patch.ErrInvalidPatch)
+}
+
+func principal(userID uint, permissions ...enum.Permission) *policy.Principal {
+	role := &models.Role{}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, models.Permission{Name: permission})
+	}
+	return &policy.Principal{User: models.User{ID: userID}, Role: role}
+}
+
+func TestCanPatchUserField(t *testing.T) {
+	owner := principal(1, enum.UsersWrite)
+	tenantAdmin := principal(2, enum.UsersManage)
+	admin := principal(3, enum.UsersManage, enum.TenantsAll)
+
+	tests := []struct {
+		name      string
+		principal *policy.Principal
+		field     string
+		want      bool
+	}{
+		{"owner renames themselves", owner, "name", true},
+		{"owner changes their email", owner, "email", true},
+		{"owner changes their password", owner, "password", true},
+		{"owner changes their role", owner, "role", false},
+		{"owner changes their company", owner, "company_id", false},
+		{"tenant admin changes a role", tenantAdmin, "role", true},
+		{"tenant admin moves a user", tenantAdmin, "company_id", false},
+		{"admin moves a user", admin, "company_id", true},
+		{"unknown field", admin, "version", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.CanPatchUserField(tt.principal, 1, &models.Role{}, tt.field))
+		})
+	}
+	assert.False(t, policy.CanPatchUserField(owner, 2, &models.Role{}, "name"), "patching someone else's name")
+
+	// A target whose role grants more than the principal holds is out of reach, field by field
+	superAdmin := principal(4, enum.UsersManage, enum.TenantsAll, enum.RolesWrite).Role
+	for _, field := range []string{"name", "email", "password", "role", "company_id"} {
+		assert.False(t, policy.CanPatchUserField(tenantAdmin, 4, superAdmin, field), "tenant admin patching a super admin's %s", field)
+		assert.False(t, policy.CanPatchUserField(admin, 4, superAdmin, field), "admin patching a more powerful user's %s", field)
+		assert.False(t, policy.CanPatchUserField(tenantAdmin, 1, nil, field), "patching a user of an unknown role")
+	}
+	assert.True(t, policy.CanPatchUserField(admin, 1, tenantAdmin.Role, "role"), "admin demoting a tenant admin")
+	assert.True(t, policy.CanPatchUserField(admin, 1, tenantAdmin.Role, "company_id"), "admin moving a tenant admin")
+}
+
+func TestPatchUserWhitelist(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme, globex := createCompany(t, db, "Acme"), createCompany(t, db, "Globex")
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.User, CompanyID: &acme.ID}, "secret-password")
+	token := login(t, handler, "ada@acme.com", "secret-password")["access_token"].(string)
+	path := fmt.Sprintf("/user/%d", user.ID)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        interface{}
+		want        int
+	}{
+		{"role by merge patch", patch.MergePatchType, map[string]interface{}{"role": "admin"}, http.StatusForbidden},
+		{"company by merge patch", patch.MergePatchType, map[string]interface{}{"company_id": globex.ID}, http.StatusForbidden},
+		{"company removed by merge patch", patch.MergePatchType, map[string]interface{}{"company_id": nil}, http.StatusForbidden},
+		{"role by JSON patch", patch.JSONPatchType, []map[string]interface{}{{"op": "replace", "path": "/role", "value": "admin"}}, http.StatusForbidden},
+		{"name along with role", patch.JSONPatchType, []map[string]interface{}{
+			{"op": "replace", "path": "/name", "value": "Ada L."},
+			{"op": "replace", "path": "/role", "value": "admin"},
+		}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodPatch, path, token, tt.body, "Content-Type", tt.contentType, "If-Match", `"1"`)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+
+	var unchanged models.User
+	require.NoError(t, db.First(&unchanged, user.ID).Error)
+	assert.Equal(t, enum.User, unchanged.Role)
+	assert.Equal(t, "Ada", unchanged.Name)
+	assert.Equal(t, acme.ID, *unchanged.CompanyID)
+
+	w := serve(handler, http.MethodPatch, path, token, map[string]interface{}{"name": "Ada L."}, "Content-Type", patch.MergePatchType, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, "Ada L.", decode(t, w)["user"].(map[string]interface{})["name"])
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"fmt"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createPost(t *testing.T, db *gorm.DB, post models.Post) models.Post {
+	require.NoError(t, db.Create(&post).Error)
+	return post
+}
+
+func TestPublishedPostsAreReadableAnonymously(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme, globex := createCompany(t, db, "Acme"), createCompany(t, db, "Globex")
+	author := createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.User, CompanyID: &acme.ID}, "secret-password")
+	createUser(t, db, models.User{Name: "Hank", Email: "hank@globex.com", Role: enum.User, CompanyID: &globex.ID}, "secret-password")
+	past := time.Now().Add(-time.Hour)
+	published := createPost(t, db, models.Post{Title: "Hello", UserId: author.ID, Status: enum.Published, PublishedAt: &past})
+	draft := createPost(t, db, models.Post{Title: "Draft", UserId: author.ID, Status: enum.Draft})
+	stranger := login(t, handler, "hank@globex.com", "secret-password")["access_token"].(string)
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"published post", fmt.Sprintf("/getPost/%d", published.ID), "", http.StatusOK},
+		{"draft", fmt.Sprintf("/getPost/%d", draft.ID), "", http.StatusNotFound},
+		{"author included", fmt.Sprintf("/getPost/%d?include=user", published.ID), "", http.StatusUnauthorized},
+		{"invalid token", fmt.Sprintf("/getPost/%d", published.ID), "not-a-token", http.StatusUnauthorized},
+		{"signed in at another tenant", fmt.Sprintf("/getPost/%d", published.ID), stranger, http.StatusNotFound},
+		{"author's posts", fmt.Sprintf("/getAllPosts/%d", author.ID), "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodGet, tt.path, tt.token, nil)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+
+	w := serve(handler, http.MethodGet, fmt.Sprintf("/getAllPosts/%d", author.ID), "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	posts := decode(t, w)["data"].([]interface{})
+	require.Len(t, posts, 1)
+	assert.Equal(t, "Hello", posts[0].(map[string]interface{})["title"])
+}
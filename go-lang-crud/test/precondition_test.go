@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/repository"
+	"golang-crud/tenant"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagDependsOnTheRepresentation(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	admin := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.Admin}, "secret-password")
+	token := login(t, handler, "ada@example.com", "secret-password")["access_token"].(string)
+	path := fmt.Sprintf("/user/%d", admin.ID)
+
+	full := serve(handler, http.MethodGet, path, token, nil)
+	require.Equal(t, http.StatusOK, full.Code, full.Body.String())
+	assert.Equal(t, `"1"`, full.Header().Get("ETag"))
+
+	projected := serve(handler, http.MethodGet, path+"?fields=name", token, nil)
+	require.Equal(t, http.StatusOK, projected.Code)
+	assert.NotEmpty(t, projected.Header().Get("ETag"))
+	assert.NotEqual(t, full.Header().Get("ETag"), projected.Header().Get("ETag"))
+	reordered := serve(handler, http.MethodGet, path+"?fields=email,name", token, nil)
+	assert.Equal(t, serve(handler, http.MethodGet, path+"?fields=name,email", token, nil).Header().Get("ETag"), reordered.Header().Get("ETag"))
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"full representation unchanged", path, full.Header().Get("ETag"), http.StatusNotModified},
+		{"projection unchanged", path + "?fields=name", projected.Header().Get("ETag"), http.StatusNotModified},
+		{"full tag on a projection", path + "?fields=name", full.Header().Get("ETag"), http.StatusOK},
+		{"projection tag on the full representation", path, projected.Header().Get("ETag"), http.StatusOK},
+		{"includes are never cached", path + "?include=company", "*", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodGet, tt.path, token, nil, "If-None-Match", tt.ifNoneMatch)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	included := serve(handler, http.MethodGet, path+"?include=company", token, nil)
+	assert.Empty(t, included.Header().Get("ETag"))
+}
+
+func TestCompanyMembershipBumpsUserVersion(t *testing.T) {
+	ctx := tenant.System(context.Background())
+	db := newDB(t)
+	company := createCompany(t, db, "Acme")
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.User}, "password")
+	companies := repository.NewCompanyRepository(db)
+	version := func() uint {
+		var found models.User
+		require.NoError(t, db.First(&found, user.ID).Error)
+		return found.Version
+	}
+
+	require.NoError(t, companies.AssignUser(ctx, fmt.Sprint(company.ID), fmt.Sprint(user.ID)))
+	assert.EqualValues(t, 2, version())
+	require.NoError(t, companies.RemoveUser(ctx, fmt.Sprint(company.ID), fmt.Sprint(user.ID)))
+	assert.EqualValues(t, 3, version())
+
+	// A delete checked against the version before the move is refused
+	err := repository.NewUserRepository(db).Delete(ctx, fmt.Sprint(user.ID), 1)
+	assert.ErrorIs(t, err, custom_error.ErrPreconditionFailed)
+	assert.NoError(t, db.First(&models.User{}, user.ID).Error)
+}
+
+func TestDeleteRequiresCurrentVersion(t *testing.T) {
+	ctx := tenant.System(context.Background())
+	db := newDB(t)
+	company := createCompany(t, db, "Acme")
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.User, CompanyID: &company.ID}, "password")
+	companies := repository.NewCompanyRepository(db)
+	users := repository.NewUserRepository(db)
+
+	// Another request renamed both in between
+	require.NoError(t, db.Model(&models.Company{}).Where("id = ?", company.ID).Update("version", 2).Error)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("version", 2).Error)
+
+	assert.ErrorIs(t, companies.DeleteById(ctx, fmt.Sprint(company.ID), 1), custom_error.ErrPreconditionFailed)
+	assert.ErrorIs(t, users.Delete(ctx, fmt.Sprint(user.ID), 1), custom_error.ErrPreconditionFailed)
+	assert.NoError(t, db.First(&models.Company{}, company.ID).Error)
+	assert.NoError(t, db.First(&models.User{}, user.ID).Error)
+
+	require.NoError(t, users.Delete(ctx, fmt.Sprint(user.ID), 2))
+	require.NoError(t, companies.DeleteById(ctx, fmt.Sprint(company.ID), 2))
+	assert.Error(t, db.First(&models.Company{}, company.ID).Error)
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/enum"
+	"golang-crud/filter"
+	"golang-crud/models"
+	"golang-crud/pagination"
+	"golang-crud/patch"
+	"golang-crud/repository"
+	"golang-crud/tenant"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantsOnlySeeTheirOwnCompany(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme, globex := createCompany(t, db, "Acme"), createCompany(t, db, "Globex")
+	createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.TenantAdmin, CompanyID: &acme.ID}, "secret-password")
+	colleague := createUser(t, db, models.User{Name: "Alan", Email: "alan@acme.com", Role: enum.User, CompanyID: &acme.ID}, "password")
+	stranger := createUser(t, db, models.User{Name: "Hank", Email: "hank@globex.com", Role: enum.User, CompanyID: &globex.ID}, "password")
+	token := login(t, handler, "ada@acme.com", "secret-password")["access_token"].(string)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"user of the same company", fmt.Sprintf("/user/%d", colleague.ID), http.StatusOK},
+		{"user of another company", fmt.Sprintf("/user/%d", stranger.ID), http.StatusNotFound},
+		{"own company", fmt.Sprintf("/company/%d", acme.ID), http.StatusOK},
+		{"another company", fmt.Sprintf("/company/%d", globex.ID), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodGet, tt.path, token, nil)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+
+	w := serve(handler, http.MethodGet, "/user/paginated", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var emails []string
+	for _, user := range decode(t, w)["data"].([]interface{}) {
+		emails = append(emails, user.(map[string]interface{})["email"].(string))
+	}
+	assert.ElementsMatch(t, []string{"ada@acme.com", "alan@acme.com"}, emails)
+}
+
+func TestQueriesWithoutScopeFail(t *testing.T) {
+	db := newDB(t)
+	company := createCompany(t, db, "Acme")
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.User, CompanyID: &company.ID}, "password")
+	users := repository.NewUserRepository(db)
+	ctx := context.Background()
+
+	_, err := users.FindById(ctx, fmt.Sprint(user.ID), nil)
+	assert.ErrorIs(t, err, tenant.ErrNoScope)
+	_, err = users.FindByEmail(ctx, "ada@acme.com")
+	assert.ErrorIs(t, err, tenant.ErrNoScope)
+	_, err = repository.NewCompanyRepository(db).FindById(ctx, fmt.Sprint(company.ID), nil)
+	assert.ErrorIs(t, err, tenant.ErrNoScope)
+
+	found, err := users.FindByEmail(tenant.System(ctx), "ada@acme.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	// A scope of another company hides the user
+	_, err = users.FindById(tenant.WithScope(ctx, tenant.Scope{CompanyID: company.ID + 1}), fmt.Sprint(user.ID), nil)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, tenant.ErrNoScope)
+}
+
+func TestPurgeRequiresSystemScope(t *testing.T) {
+	db := newDB(t)
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.User}, "password")
+	require.NoError(t, db.Delete(&user).Error)
+	trash := repository.NewTrashRepository(db)
+	future := time.Now().Add(time.Hour)
+
+	_, err := trash.Purge(context.Background(), future)
+	assert.ErrorIs(t, err, tenant.ErrNoScope)
+
+	purged, err := trash.Purge(tenant.System(context.Background()), future)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged)
+}
+
+func TestTenantAdminsCannotActOnMorePowerfulUsers(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme := createCompany(t, db, "Acme")
+	createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.TenantAdmin, CompanyID: &acme.ID}, "secret-password")
+	admin := createUser(t, db, models.User{Name: "Root", Email: "root@acme.com", Role: enum.Admin, CompanyID: &acme.ID}, "root-password")
+	colleague := createUser(t, db, models.User{Name: "Alan", Email: "alan@acme.com", Role: enum.User, CompanyID: &acme.ID}, "password")
+	token := login(t, handler, "ada@acme.com", "secret-password")["access_token"].(string)
+	path := fmt.Sprintf("/user/%d", admin.ID)
+
+	tests := []struct {
+		name   string
+		method string
+		body   interface{}
+		header []string
+	}{
+		{"edit", http.MethodPut, map[string]string{"name": "Root", "email": "ada@example.com"}, []string{"If-Match", `"1"`}},
+		{"take over", http.MethodPatch, []map[string]interface{}{{"op": "add", "path": "/password", "value": "new-password"}},
+			[]string{"Content-Type", patch.JSONPatchType, "If-Match", `"1"`}},
+		{"demote", http.MethodPatch, map[string]interface{}{"role": enum.User}, []string{"Content-Type", patch.MergePatchType, "If-Match", `"1"`}},
+		{"delete", http.MethodDelete, nil, []string{"If-Match", `"1"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.method, path, token, tt.body, tt.header...)
+			assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+		})
+	}
+
+	var unchanged models.User
+	require.NoError(t, db.First(&unchanged, admin.ID).Error)
+	assert.Equal(t, enum.Admin, unchanged.Role)
+	assert.Equal(t, "root@acme.com", unchanged.Email)
+	login(t, handler, "root@acme.com", "root-password")
+
+	// Users with no more permissions than the tenant admin remain theirs to manage
+	w := serve(handler, http.MethodPatch, fmt.Sprintf("/user/%d", colleague.ID), token,
+		map[string]interface{}{"role": enum.TenantAdmin}, "Content-Type", patch.MergePatchType, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serve(handler, http.MethodDelete, fmt.Sprintf("/user/%d", colleague.ID), token, nil, "If-Match", `"2"`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestPublicScopeOnlySeesPublishedPosts(t *testing.T) {
+	db := newDB(t)
+	company := createCompany(t, db, "Acme")
+	author := createUser(t, db, models.User{Name: "Ada", Email: "ada@acme.com", Role: enum.User, CompanyID: &company.ID}, "password")
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	published := createPost(t, db, models.Post{Title: "Hello", UserId: author.ID, Status: enum.Published, PublishedAt: &past})
+	scheduled := createPost(t, db, models.Post{Title: "Soon", UserId: author.ID, Status: enum.Published, PublishedAt: &future})
+	draft := createPost(t, db, models.Post{Title: "Draft", UserId: author.ID, Status: enum.Draft})
+	ctx := tenant.Public(context.Background())
+
+	_, err := repository.NewUserRepository(db).FindById(ctx, fmt.Sprint(author.ID), nil)
+	assert.ErrorIs(t, err, tenant.ErrNotPublic)
+	_, err = repository.NewCompanyRepository(db).FindById(ctx, fmt.Sprint(company.ID), nil)
+	assert.ErrorIs(t, err, tenant.ErrNotPublic)
+
+	posts := repository.NewPostRepository(db)
+	found, err := posts.FindById(ctx, fmt.Sprint(published.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", found.Title)
+	for _, hidden := range []models.Post{scheduled, draft} {
+		_, err := posts.FindById(ctx, fmt.Sprint(hidden.ID), nil)
+		assert.ErrorIs(t, err, custom_error.ErrPostNotFound, hidden.Title)
+	}
+
+	// Even when a handler forgets to leave out unpublished posts
+	page, err := posts.FindByUserId(ctx, fmt.Sprint(author.ID), true, &filter.Query{}, nil, pagination.Params{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, published.ID, page.Items[0].ID)
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/security"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func refresh(handler http.Handler, refreshToken string) (int, map[string]interface{}) {
+	w := serve(handler, http.MethodPost, "/token/refresh", "", map[string]string{"refresh_token": refreshToken})
+	body := map[string]interface{}{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func storedToken(t *testing.T, db *gorm.DB, raw string) models.RefreshToken {
+	var token models.RefreshToken
+	require.NoError(t, db.Where("token_hash = ?", security.HashRefreshToken(raw)).First(&token).Error)
+	return token
+}
+
+func newTokenApp(t *testing.T) (http.Handler, *gorm.DB, string) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	company := createCompany(t, db, "Acme")
+	user := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.User, CompanyID: &company.ID}, "secret-password")
+	return handler, db, fmt.Sprintf("/user/%d", user.ID)
+}
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	handler, db, _ := newTokenApp(t)
+	first := login(t, handler, "ada@example.com", "secret-password")
+
+	code, second := refresh(handler, first["refresh_token"].(string))
+	require.Equal(t, http.StatusOK, code, second)
+	assert.NotEqual(t, first["refresh_token"], second["refresh_token"])
+	assert.NotEqual(t, first["access_token"], second["access_token"])
+
+	old, next := storedToken(t, db, first["refresh_token"].(string)), storedToken(t, db, second["refresh_token"].(string))
+	assert.NotNil(t, old.RevokedAt)
+	require.NotNil(t, old.ReplacedBy)
+	assert.Equal(t, next.ID, *old.ReplacedBy)
+	assert.Equal(t, old.FamilyID, next.FamilyID)
+	assert.Nil(t, next.RevokedAt)
+}
+
+func TestRefreshTokenReuseRevokesTheSession(t *testing.T) {
+	handler, db, profile := newTokenApp(t)
+	first := login(t, handler, "ada@example.com", "secret-password")
+	code, second := refresh(handler, first["refresh_token"].(string))
+	require.Equal(t, http.StatusOK, code, second)
+
+	// Someone replays the rotated token
+	code, body := refresh(handler, first["refresh_token"].(string))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Contains(t, fmt.Sprint(body), "reuse")
+
+	// The whole session is gone, including the legitimate successor
+	assert.NotNil(t, storedToken(t, db, second["refresh_token"].(string)).RevokedAt)
+	code, _ = refresh(handler, second["refresh_token"].(string))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	w := serve(handler, http.MethodGet, profile, second["access_token"].(string), nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Other sessions are untouched
+	other := login(t, handler, "ada@example.com", "secret-password")
+	w = serve(handler, http.MethodGet, profile, other["access_token"].(string), nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestLogoutRejectsTheAccessToken(t *testing.T) {
+	handler, _, profile := newTokenApp(t)
+	tokens := login(t, handler, "ada@example.com", "secret-password")
+	access := tokens["access_token"].(string)
+
+	w := serve(handler, http.MethodGet, profile, access, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(handler, http.MethodPost, "/logout", "", map[string]string{"refresh_token": tokens["refresh_token"].(string)})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The access token hasn't expired, but its session is no longer active
+	w = serve(handler, http.MethodGet, profile, access, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	code, _ := refresh(handler, tokens["refresh_token"].(string))
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestExpiredRefreshTokenIsRejected(t *testing.T) {
+	handler, db, _ := newTokenApp(t)
+	tokens := login(t, handler, "ada@example.com", "secret-password")
+	stored := storedToken(t, db, tokens["refresh_token"].(string))
+	require.NoError(t, db.Model(&stored).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	code, _ := refresh(handler, tokens["refresh_token"].(string))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = refresh(handler, "unknown-token")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/repository"
+	"golang-crud/tenant"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashedUsersReleaseTheirEmail(t *testing.T) {
+	db := newDB(t)
+	trashed := createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.User}, "password")
+	require.NoError(t, db.Delete(&trashed).Error)
+
+	// The email is free again once its user is in the trash
+	createUser(t, db, models.User{Name: "Ada Again", Email: "ada@example.com", Role: enum.User}, "password")
+
+	duplicate := models.User{Name: "Ada Twice", Email: "ada@example.com", Password: "x", Role: enum.User}
+	assert.Error(t, db.Create(&duplicate).Error)
+
+	// Restoring the trashed user would make two live users share it
+	err := repository.NewTrashRepository(db).RestoreUser(tenant.System(context.Background()), fmt.Sprint(trashed.ID))
+	assert.Error(t, err)
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Where("email = ?", "ada@example.com").Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestListTrash(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	createUser(t, db, models.User{Name: "Ada", Email: "ada@example.com", Role: enum.Admin}, "secret-password")
+	trashed := createUser(t, db, models.User{Name: "Alan", Email: "alan@example.com", Role: enum.User}, "password")
+	require.NoError(t, db.Delete(&trashed).Error)
+	token := login(t, handler, "ada@example.com", "secret-password")["access_token"].(string)
+
+	tests := []struct {
+		resource string
+		want     int
+		count    int
+	}{
+		{"users", http.StatusOK, 1},
+		{"posts", http.StatusOK, 0},
+		{"companies", http.StatusOK, 0},
+		{"roles", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			w := serve(handler, http.MethodGet, "/admin/trash/"+tt.resource, token, nil)
+			require.Equal(t, tt.want, w.Code, w.Body.String())
+			if tt.want == http.StatusOK {
+				assert.Len(t, decode(t, w)[tt.resource], tt.count)
+			}
+		})
+	}
+}
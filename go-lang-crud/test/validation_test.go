@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-crud/custom_error"
+	"golang-crud/dto"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/patch"
+	"golang-crud/tenant"
+	"golang-crud/validation"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emailLookup finds users in a map of email to user ID.
+type emailLookup struct {
+	users  map[string]uint
+	err    error
+	scopes []tenant.Scope
+}
+
+func (l *emailLookup) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	scope, _ := tenant.FromContext(ctx)
+	l.scopes = append(l.scopes, scope)
+	if l.err != nil {
+		return nil, l.err
+	}
+	id, ok := l.users[email]
+	if !ok {
+		return nil, custom_error.ErrUserNotFound
+	}
+	return &models.User{ID: id, Email: email}, nil
+}
+
+// rules lists the rules the payload broke, by field.
+func rules(t *testing.T, err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	var fieldErrors validation.Errors
+	require.True(t, errors.As(err, &fieldErrors), "%v", err)
+	broken := map[string]string{}
+	for _, fieldError := range fieldErrors {
+		broken[fieldError.Field] = fieldError.Rule
+		assert.NotEmpty(t, fieldError.Message)
+	}
+	return broken
+}
+
+func TestPasswordRule(t *testing.T) {
+	v := validation.New(nil)
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"Secret123", true},
+		{"Pässwört1", true},
+		{"Abcdefg1", true},
+		{"Abcdef1", false},
+		{"secret123", false},
+		{"SECRET123", false},
+		{"SecretPassword", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			err := v.Struct(context.Background(), dto.CreateUserRequest{Name: "Ada", Email: "ada@acme.com", Password: tt.password})
+			if tt.valid {
+				assert.NoError(t, err)
+			} else if tt.password == "" {
+				assert.Equal(t, map[string]string{"password": "required"}, rules(t, err))
+			} else {
+				assert.Equal(t, map[string]string{"password": "password"}, rules(t, err))
+			}
+		})
+	}
+}
+
+func TestUniqueEmailRule(t *testing.T) {
+	emails := &emailLookup{users: map[string]uint{"ada@acme.com": 1}}
+	v := validation.New(emails)
+	request := func(email string) dto.UpdateUserRequest {
+		return dto.UpdateUserRequest{Name: "Ada", Email: email}
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, v.Struct(ctx, request("alan@acme.com")))
+	assert.Equal(t, map[string]string{"email": "unique_email"}, rules(t, v.Struct(ctx, request("ada@acme.com"))))
+	assert.NoError(t, v.Struct(validation.ExcludingUser(ctx, 1), request("ada@acme.com")), "users keep their own email")
+	assert.Equal(t, map[string]string{"email": "unique_email"}, rules(t, v.Struct(validation.ExcludingUser(ctx, 2), request("ada@acme.com"))))
+
+	// Emails are looked up across tenants, whoever validates
+	for _, scope := range emails.scopes {
+		assert.True(t, scope.AllTenants)
+	}
+
+	// The unique index still guards the column when the lookup fails
+	emails.err = errors.New("connection refused")
+	assert.NoError(t, v.Struct(ctx, request("ada@acme.com")))
+}
+
+func TestPostStatusRule(t *testing.T) {
+	v := validation.New(nil)
+	for _, status := range []enum.PostStatus{"", enum.Draft, enum.Published, enum.Archived, "deleted", "Published"} {
+		t.Run(string(status), func(t *testing.T) {
+			err := v.Struct(context.Background(), dto.CreatePostRequest{Title: "Hello", Body: "World", Status: status})
+			if status == "" || status.IsValid() {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, map[string]string{"status": "post_status"}, rules(t, err))
+			}
+		})
+	}
+}
+
+func TestInvalidPayloads(t *testing.T) {
+	db := newDB(t)
+	handler := newApp(t, db).Handler()
+	acme := createCompany(t, db, "Acme")
+	admin := createUser(t, db, models.User{Name: "Root", Email: "root@acme.com", Role: enum.Admin, CompanyID: &acme.ID}, "root-password")
+	token := login(t, handler, "root@acme.com", "root-password")["access_token"].(string)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    interface{}
+		headers []string
+		want    []validation.FieldError
+	}{
+		{"every broken rule", http.MethodPost, "/user/", map[string]interface{}{"email": "root@acme.com", "password": "weak"}, nil, []validation.FieldError{
+			{Field: "name", Rule: "required", Message: "is required"},
+			{Field: "email", Rule: "unique_email", Message: "is already registered"},
+			{Field: "password", Rule: "password", Message: "must be at least 8 characters and contain an upper case letter, a lower case letter and a digit"},
+		}},
+		{"wrong type", http.MethodPost, "/user/", map[string]interface{}{"name": 42}, nil, []validation.FieldError{
+			{Field: "name", Rule: "type", Message: "must be a string"},
+		}},
+		{"unknown member in a patch", http.MethodPatch, fmt.Sprintf("/user/%d", admin.ID), map[string]interface{}{"nickname": "root"},
+			[]string{"Content-Type", patch.MergePatchType, "If-Match", `"1"`}, []validation.FieldError{
+				{Field: "nickname", Rule: "unknown", Message: "is not a known field"},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.method, tt.path, token, tt.body, tt.headers...)
+			require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+			var problem struct {
+				Title  string                  `json:"title"`
+				Status int                     `json:"status"`
+				Detail string                  `json:"detail"`
+				Errors []validation.FieldError `json:"errors"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "Unprocessable Entity", problem.Title)
+			assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+			assert.Equal(t, "Validation failed", problem.Detail)
+			assert.Equal(t, tt.want, problem.Errors)
+		})
+	}
+
+	w := serve(handler, http.MethodPost, "/user/", token, nil, "Content-Type", "application/json")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "an empty body isn't JSON")
+}
@@ -0,0 +1,164 @@
+// Package validation runs the validate struct tags of request payloads and
+// reports every invalid field with the rule it broke.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-crud/enum"
+	"golang-crud/models"
+	"golang-crud/tenant"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one invalid field of a payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists the invalid fields of a payload.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Field + " " + fieldError.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// EmailLookup finds the user registered with an email address, if any.
+type EmailLookup interface {
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+type Validator struct {
+	validate *validator.Validate
+	emails   EmailLookup
+}
+
+// New creates a validator with the application's custom rules:
+// password (strength), unique_email and post_status.
+func New(emails EmailLookup) *Validator {
+	v := &Validator{validate: validator.New(validator.WithRequiredStructEnabled()), emails: emails}
+
+	// Report fields by their JSON names
+	v.validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	v.validate.RegisterValidation("password", strongPassword)
+	v.validate.RegisterValidation("post_status", func(fl validator.FieldLevel) bool {
+		return enum.PostStatus(fl.Field().String()).IsValid()
+	})
+	v.validate.RegisterValidationCtx("unique_email", v.uniqueEmail)
+	return v
+}
+
+// Struct validates a payload, returning Errors when any field is invalid.
+func (v *Validator) Struct(ctx context.Context, payload interface{}) error {
+	err := v.validate.StructCtx(ctx, payload)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	result := make(Errors, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		result[i] = FieldError{
+			Field:   fieldPath(fieldError.Namespace()),
+			Rule:    fieldError.Tag(),
+			Message: message(fieldError),
+		}
+	}
+	return result
+}
+
+// fieldPath drops the struct name the namespace starts with.
+func fieldPath(namespace string) string {
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+func message(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fieldError.Param() == "1" {
+			return "must not be empty"
+		}
+		return fmt.Sprintf("must be at least %s characters", fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fieldError.Param())
+	case "password":
+		return "must be at least 8 characters and contain an upper case letter, a lower case letter and a digit"
+	case "unique_email":
+		return "is already registered"
+	case "post_status":
+		return fmt.Sprintf("must be one of %s, %s or %s", enum.Draft, enum.Published, enum.Archived)
+	}
+	return "is invalid"
+}
+
+func strongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	var upper, lower, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return len([]rune(password)) >= 8 && upper && lower && digit
+}
+
+type excludedUserKey struct{}
+
+// ExcludingUser lets the user with the given ID keep their own email when
+// unique_email is checked, e.g. while they update their profile.
+func ExcludingUser(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, excludedUserKey{}, userID)
+}
+
+// uniqueEmail passes unless another user is registered with the email. A
+// failed lookup passes too; the unique index still guards the column.
+func (v *Validator) uniqueEmail(ctx context.Context, fl validator.FieldLevel) bool {
+	if v.emails == nil {
+		return true
+	}
+
+	// Emails are unique across tenants
+	user, err := v.emails.FindByEmail(tenant.System(ctx), fl.Field().String())
+	if err != nil {
+		return true
+	}
+	excluded, ok := ctx.Value(excludedUserKey{}).(uint)
+	return ok && user.ID == excluded
+}